@@ -2,53 +2,99 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"sync"
 	"time"
 )
 
 // NodeStatus 节点状态
 type NodeStatus struct {
-	Node     ProxyNode `json:"node"`
-	Status   string    `json:"status"`   // "online", "offline", "timeout"
-	Latency  int       `json:"latency"`  // 延迟毫秒
-	Error    string    `json:"error,omitempty"`
+	Node      ProxyNode  `json:"node"`
+	Status    string     `json:"status"`  // "online", "offline", "timeout", "auth_failed", "unverified"
+	Latency   int        `json:"latency"` // 延迟毫秒，通过节点访问测试地址成功时为端到端延迟
+	Error     string     `json:"error,omitempty"`
+	Transport string     `json:"transport"` // 检测所用传输层: "tcp" 或 "udp"
+	TLS       *TLSCheck  `json:"tls,omitempty"`
+	ProxyTest *ProxyTest `json:"proxyTest,omitempty"` // 设置测试地址时通过节点访问的结果
 }
 
-// CheckNodesConnectivity 检查节点连通性
-func CheckNodesConnectivity(nodes []ProxyNode) []NodeStatus {
+// TLSCheck 启用 TLS 的节点的握手检测结果
+type TLSCheck struct {
+	ServerName       string     `json:"serverName"`
+	HandshakeLatency int        `json:"handshakeLatency"` // 握手耗时毫秒，不含 TCP 连接
+	Version          string     `json:"version,omitempty"`
+	ALPN             string     `json:"alpn,omitempty"` // 协商得到的 ALPN
+	CertSubject      string     `json:"certSubject,omitempty"`
+	CertIssuer       string     `json:"certIssuer,omitempty"`
+	CertNotAfter     *time.Time `json:"certNotAfter,omitempty"`
+	CertExpiresIn    int        `json:"certExpiresIn"` // 证书剩余有效天数，已过期时为负数
+	Verified         bool       `json:"verified"`
+	VerifyError      string     `json:"verifyError,omitempty"`
+}
+
+// udpProtocols 基于 UDP (QUIC) 传输的协议，无法通过 TCP 连接检测
+var udpProtocols = map[string]bool{
+	"hysteria2": true,
+	"tuic":      true,
+}
+
+// udpProbeTimeout UDP 探测包等待响应的时间
+const udpProbeTimeout = 1500 * time.Millisecond
+
+// tlsHandshakeTimeout TLS 握手超时时间
+const tlsHandshakeTimeout = 5 * time.Second
+
+// tlsCheckRootCAs 校验节点证书使用的根证书，为 nil 时使用系统根证书
+var tlsCheckRootCAs *x509.CertPool
+
+// CheckNodesConnectivity 检查节点连通性，testURL 不为空时还会按节点协议通过节点访问该地址
+func CheckNodesConnectivity(nodes []ProxyNode, testURL string) []NodeStatus {
 	var wg sync.WaitGroup
 	results := make([]NodeStatus, len(nodes))
-	
+
 	// 并发检查每个节点
 	for i, node := range nodes {
 		wg.Add(1)
 		go func(index int, n ProxyNode) {
 			defer wg.Done()
-			results[index] = checkSingleNode(n)
+			results[index] = checkSingleNode(n, testURL)
 		}(i, node)
 	}
-	
+
 	wg.Wait()
 	return results
 }
 
 // checkSingleNode 检查单个节点
-func checkSingleNode(node ProxyNode) NodeStatus {
+func checkSingleNode(node ProxyNode, testURL string) NodeStatus {
+	if udpProtocols[node.Type] {
+		status := checkUDPNode(node)
+		if testURL != "" {
+			status.ProxyTest = &ProxyTest{URL: testURL, Skipped: proxyTestUnsupported(node)}
+		}
+		return status
+	}
+
 	status := NodeStatus{
-		Node:    node,
-		Status:  "offline",
-		Latency: -1,
+		Node:      node,
+		Status:    "offline",
+		Latency:   -1,
+		Transport: "tcp",
 	}
 
 	// 构建地址
-	address := fmt.Sprintf("%s:%d", node.Server, node.Port)
-	
+	address := net.JoinHostPort(node.Server, strconv.Itoa(node.Port))
+
 	// 设置超时时间
 	timeout := 5 * time.Second
 	start := time.Now()
-	
+
 	// 尝试TCP连接
 	conn, err := net.DialTimeout("tcp", address, timeout)
 	if err != nil {
@@ -60,41 +106,222 @@ func checkSingleNode(node ProxyNode) NodeStatus {
 		return status
 	}
 	defer conn.Close()
-	
+
 	// 计算延迟
 	latency := time.Since(start)
-	status.Status = "online"
 	status.Latency = int(latency.Milliseconds())
-	
+
+	// TCP 可达不代表节点可用，证书过期或 SNI 错误时客户端同样无法连接
+	if usesTLS(node) {
+		tlsConn, check, err := checkTLSHandshake(conn, node)
+		status.TLS = check
+		if err != nil {
+			status.Error = err.Error()
+			if isTimeoutError(err) {
+				status.Status = "timeout"
+			}
+			return status
+		}
+		conn = tlsConn
+	}
+
+	// 连接成功也不代表 UUID/密码正确，只有按节点协议访问测试地址才能确认节点可用
+	if testURL != "" {
+		status.ProxyTest = &ProxyTest{URL: testURL, Skipped: proxyTestUnsupported(node)}
+		if status.ProxyTest.Skipped == "" {
+			statusCode, err := fetchThroughProxy(conn, node, testURL)
+			if err != nil {
+				status.Error = err.Error()
+				switch {
+				case errors.Is(err, errProxyAuth):
+					status.Status = "auth_failed"
+				case isTimeoutError(err):
+					status.Status = "timeout"
+				}
+				return status
+			}
+			status.ProxyTest.StatusCode = statusCode
+			status.ProxyTest.Latency = int(time.Since(start).Milliseconds())
+			status.Latency = status.ProxyTest.Latency
+		}
+	}
+
+	status.Status = "online"
 	return status
 }
 
-// FilterOnlineNodes 过滤在线节点
-func FilterOnlineNodes(statuses []NodeStatus) []ProxyNode {
+// isTimeoutError 判断错误是否为超时
+func isTimeoutError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// usesTLS 判断节点是否需要检测 TLS 握手
+// Reality 使用 uTLS 与自定义认证，标准 TLS 握手会被转发到伪装站点，无法反映节点状态，因此只检测 TCP
+func usesTLS(node ProxyNode) bool {
+	return node.TLS != nil && *node.TLS && node.RealityOpts == nil
+}
+
+// tlsServerName 返回客户端握手时使用的 SNI：优先 servername，其次 WebSocket/HTTP 的 Host，最后为服务器地址
+func tlsServerName(node ProxyNode) string {
+	if node.SNI != "" {
+		return node.SNI
+	}
+	if node.WSOpts != nil && node.WSOpts.Headers["Host"] != "" {
+		return node.WSOpts.Headers["Host"]
+	}
+	if node.HTTPOpts != nil && node.HTTPOpts.Headers["Host"] != "" {
+		return node.HTTPOpts.Headers["Host"]
+	}
+	return node.Server
+}
+
+// checkTLSHandshake 在已建立的 TCP 连接上按节点的 SNI、ALPN 设置完成 TLS 握手并检查证书，返回可继续使用的 TLS 连接
+// 握手本身不校验证书，以便在证书无效时仍能返回证书信息；未设置 skip-cert-verify 的节点证书校验失败时返回错误
+func checkTLSHandshake(conn net.Conn, node ProxyNode) (*tls.Conn, *TLSCheck, error) {
+	serverName := tlsServerName(node)
+	check := &TLSCheck{ServerName: serverName}
+
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName:         serverName,
+		NextProtos:         tlsNextProtos(node),
+		InsecureSkipVerify: true,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), tlsHandshakeTimeout)
+	defer cancel()
+	start := time.Now()
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, check, fmt.Errorf("TLS 握手失败: %w", err)
+	}
+	check.HandshakeLatency = int(time.Since(start).Milliseconds())
+
+	state := tlsConn.ConnectionState()
+	check.Version = tls.VersionName(state.Version)
+	check.ALPN = state.NegotiatedProtocol
+	if len(state.PeerCertificates) == 0 {
+		check.VerifyError = "服务端未提供证书"
+	} else {
+		leaf := state.PeerCertificates[0]
+		check.CertSubject = leaf.Subject.String()
+		check.CertIssuer = leaf.Issuer.String()
+		check.CertNotAfter = &leaf.NotAfter
+		check.CertExpiresIn = int(time.Until(leaf.NotAfter).Hours() / 24)
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range state.PeerCertificates[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := leaf.Verify(x509.VerifyOptions{
+			DNSName:       serverName,
+			Intermediates: intermediates,
+			Roots:         tlsCheckRootCAs,
+		})
+		if err != nil {
+			check.VerifyError = err.Error()
+		} else {
+			check.Verified = true
+		}
+	}
+
+	if !check.Verified && !node.SkipCertVerify {
+		return nil, check, fmt.Errorf("证书校验失败: %s", check.VerifyError)
+	}
+	return tlsConn, check, nil
+}
+
+// tlsNextProtos 返回握手时提供的 ALPN，WebSocket 需要 HTTP/1.1 升级连接，与客户端一样只提供 http/1.1
+func tlsNextProtos(node ProxyNode) []string {
+	if node.Network == "ws" {
+		return []string{"http/1.1"}
+	}
+	return node.ALPN
+}
+
+// checkUDPNode 检查基于 UDP 的节点 (Hysteria2、TUIC 等)
+// UDP 无连接，只能发送探测包：收到 ICMP 端口不可达视为离线，收到响应视为在线；
+// QUIC 服务端会静默丢弃无效数据包，与丢弃所有流量的失效节点无法区分，因此超时未响应视为未验证，不计入在线节点
+func checkUDPNode(node ProxyNode) NodeStatus {
+	status := NodeStatus{
+		Node:      node,
+		Status:    "offline",
+		Latency:   -1,
+		Transport: "udp",
+	}
+
+	address := net.JoinHostPort(node.Server, strconv.Itoa(node.Port))
+
+	timeout := 5 * time.Second
+	start := time.Now()
+
+	// UDP "连接" 只完成地址解析，不会产生网络交互
+	conn, err := net.DialTimeout("udp", address, timeout)
+	if err != nil {
+		status.Error = err.Error()
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			status.Status = "timeout"
+		}
+		return status
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(udpProbeTimeout))
+	if _, err := conn.Write([]byte{0}); err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	buf := make([]byte, 1500)
+	if _, err := conn.Read(buf); err != nil {
+		// 非超时错误 (如 connection refused) 说明端口不可达
+		if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+			status.Error = err.Error()
+			return status
+		}
+		status.Status = "unverified"
+		status.Error = "未收到 UDP 响应，无法确认节点是否可用"
+		return status
+	}
+
+	status.Status = "online"
+	status.Latency = int(time.Since(start).Milliseconds())
+
+	return status
+}
+
+// FilterOnlineNodes 过滤在线节点，同时保留无法验证的 UDP 节点并返回其名称
+// QUIC 服务端不会响应探测包，正常的 Hysteria2/TUIC 节点也只能得到 "unverified"，排除它们会导致这类节点全部丢失
+func FilterOnlineNodes(statuses []NodeStatus) ([]ProxyNode, []string) {
 	var onlineNodes []ProxyNode
-	
+	var unverified []string
+
 	for _, status := range statuses {
-		if status.Status == "online" {
+		switch status.Status {
+		case "online":
 			onlineNodes = append(onlineNodes, status.Node)
+		case "unverified":
+			onlineNodes = append(onlineNodes, status.Node)
+			unverified = append(unverified, status.Node.Name)
 		}
 	}
-	
-	return onlineNodes
+
+	return onlineNodes, unverified
 }
 
 // GetConnectivitySummary 获取连通性摘要
 func GetConnectivitySummary(statuses []NodeStatus) map[string]int {
 	summary := map[string]int{
-		"total":   len(statuses),
-		"online":  0,
-		"offline": 0,
-		"timeout": 0,
+		"total":       len(statuses),
+		"online":      0,
+		"offline":     0,
+		"timeout":     0,
+		"auth_failed": 0,
+		"unverified":  0,
 	}
-	
+
 	for _, status := range statuses {
 		summary[status.Status]++
 	}
-	
+
 	return summary
 }
-
@@ -0,0 +1,258 @@
+// backend/checker_test.go
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestCertificate 生成自签名证书，notAfter 早于当前时间时为过期证书
+func newTestCertificate(t *testing.T, dnsName string, notAfter time.Time) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: dnsName},
+		DNSNames:              []string{dnsName},
+		NotBefore:             notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// startTLSServer 启动使用指定证书与 ALPN 的 TLS 服务
+func startTLSServer(t *testing.T, cert tls.Certificate, nextProtos []string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	// 检测只完成握手就关闭连接，不记录服务端的握手错误
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}, NextProtos: nextProtos}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// trustCertificates 在测试期间以指定证书作为校验节点证书的根证书
+func trustCertificates(t *testing.T, certs ...*x509.Certificate) {
+	t.Helper()
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	previous := tlsCheckRootCAs
+	tlsCheckRootCAs = pool
+	t.Cleanup(func() { tlsCheckRootCAs = previous })
+}
+
+// handshakeNode 连接测试服务并按节点设置完成 TLS 握手
+func handshakeNode(t *testing.T, server *httptest.Server, node ProxyNode) (*TLSCheck, error) {
+	t.Helper()
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsEnabled := true
+	node.Type = "trojan"
+	node.Server = host
+	node.Port, _ = strconv.Atoi(port)
+	node.TLS = &tlsEnabled
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	tlsConn, check, err := checkTLSHandshake(conn, node)
+	if err == nil && tlsConn == nil {
+		t.Error("握手成功但没有返回 TLS 连接")
+	}
+	return check, err
+}
+
+func TestCheckTLSHandshake(t *testing.T) {
+	valid := newTestCertificate(t, "node.example.com", time.Now().Add(90*24*time.Hour))
+	expired := newTestCertificate(t, "node.example.com", time.Now().Add(-24*time.Hour))
+
+	t.Run("valid", func(t *testing.T) {
+		trustCertificates(t, valid.Leaf)
+		server := startTLSServer(t, valid, []string{"h2", "http/1.1"})
+		check, err := handshakeNode(t, server, ProxyNode{SNI: "node.example.com", ALPN: []string{"h2"}})
+		if err != nil {
+			t.Fatalf("checkTLSHandshake: %v", err)
+		}
+		if !check.Verified || check.ALPN != "h2" || check.ServerName != "node.example.com" || check.CertExpiresIn < 89 {
+			t.Errorf("check = %+v", check)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		trustCertificates(t, expired.Leaf)
+		server := startTLSServer(t, expired, nil)
+		check, err := handshakeNode(t, server, ProxyNode{SNI: "node.example.com"})
+		if err == nil || check.Verified || !strings.Contains(check.VerifyError, "expired") {
+			t.Errorf("err = %v, check = %+v, want expired certificate", err, check)
+		}
+		if check.CertExpiresIn >= 0 || check.CertNotAfter == nil {
+			t.Errorf("CertExpiresIn = %d, CertNotAfter = %v", check.CertExpiresIn, check.CertNotAfter)
+		}
+	})
+
+	t.Run("SNI mismatch", func(t *testing.T) {
+		trustCertificates(t, valid.Leaf)
+		server := startTLSServer(t, valid, nil)
+		check, err := handshakeNode(t, server, ProxyNode{SNI: "other.example.net"})
+		if err == nil || check.Verified || !strings.Contains(check.VerifyError, "other.example.net") {
+			t.Errorf("err = %v, check = %+v, want hostname mismatch", err, check)
+		}
+	})
+
+	t.Run("SNI from ws host", func(t *testing.T) {
+		trustCertificates(t, valid.Leaf)
+		server := startTLSServer(t, valid, nil)
+		node := ProxyNode{Network: "ws"}
+		node.WSOpts = &struct {
+			Path    string            `yaml:"path"`
+			Headers map[string]string `yaml:"headers,omitempty"`
+		}{Path: "/ws", Headers: map[string]string{"Host": "node.example.com"}}
+		if check, err := handshakeNode(t, server, node); err != nil || !check.Verified {
+			t.Errorf("err = %v, check = %+v", err, check)
+		}
+	})
+
+	t.Run("self-signed", func(t *testing.T) {
+		trustCertificates(t)
+		server := startTLSServer(t, valid, nil)
+		check, err := handshakeNode(t, server, ProxyNode{SNI: "node.example.com"})
+		if err == nil || check.Verified || check.VerifyError == "" {
+			t.Errorf("err = %v, check = %+v, want unknown authority", err, check)
+		}
+	})
+
+	t.Run("self-signed with skip-cert-verify", func(t *testing.T) {
+		trustCertificates(t)
+		server := startTLSServer(t, valid, nil)
+		check, err := handshakeNode(t, server, ProxyNode{SNI: "node.example.com", SkipCertVerify: true})
+		if err != nil {
+			t.Fatalf("checkTLSHandshake: %v", err)
+		}
+		if check.Verified || check.VerifyError == "" || !strings.Contains(check.CertSubject, "node.example.com") {
+			t.Errorf("check = %+v, want unverified certificate details", check)
+		}
+	})
+
+	t.Run("ALPN mismatch", func(t *testing.T) {
+		trustCertificates(t, valid.Leaf)
+		server := startTLSServer(t, valid, []string{"http/1.1"})
+		if _, err := handshakeNode(t, server, ProxyNode{SNI: "node.example.com", ALPN: []string{"h2"}}); err == nil {
+			t.Error("expected handshake failure for ALPN mismatch")
+		}
+	})
+
+	t.Run("ws forces http/1.1", func(t *testing.T) {
+		trustCertificates(t, valid.Leaf)
+		server := startTLSServer(t, valid, []string{"h2", "http/1.1"})
+		check, err := handshakeNode(t, server, ProxyNode{SNI: "node.example.com", Network: "ws", ALPN: []string{"h2", "http/1.1"}})
+		if err != nil {
+			t.Fatalf("checkTLSHandshake: %v", err)
+		}
+		if check.ALPN != "http/1.1" {
+			t.Errorf("ALPN = %q, want http/1.1", check.ALPN)
+		}
+	})
+}
+
+// startUDPServer 启动本机 UDP 服务，reply 为 true 时回显收到的数据包
+func startUDPServer(t *testing.T, reply bool) (string, int) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if reply {
+				conn.WriteTo(buf[:n], addr)
+			}
+		}
+	}()
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func TestCheckUDPNode(t *testing.T) {
+	silentHost, silentPort := startUDPServer(t, false)
+	replyHost, replyPort := startUDPServer(t, true)
+	// 关闭的端口会返回 ICMP 端口不可达
+	closed, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := closed.LocalAddr().(*net.UDPAddr).Port
+	closed.Close()
+
+	tests := []struct {
+		node   ProxyNode
+		status string
+	}{
+		{ProxyNode{Name: "silent", Type: "hysteria2", Server: silentHost, Port: silentPort}, "unverified"},
+		{ProxyNode{Name: "reply", Type: "tuic", Server: replyHost, Port: replyPort}, "online"},
+		{ProxyNode{Name: "closed", Type: "hysteria2", Server: "127.0.0.1", Port: closedPort}, "offline"},
+	}
+
+	var statuses []NodeStatus
+	for _, tt := range tests {
+		status := checkSingleNode(tt.node, "")
+		if status.Status != tt.status {
+			t.Errorf("%s: status = %s (%s), want %s", tt.node.Name, status.Status, status.Error, tt.status)
+		}
+		if status.Status != "online" && status.Latency != -1 {
+			t.Errorf("%s: latency = %d, want -1", tt.node.Name, status.Latency)
+		}
+		statuses = append(statuses, status)
+	}
+
+	// 仅包含在线节点时保留无法验证的 UDP 节点并单独列出
+	nodes, unverified := FilterOnlineNodes(statuses)
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	if strings.Join(names, ",") != "silent,reply" || strings.Join(unverified, ",") != "silent" {
+		t.Errorf("FilterOnlineNodes = %v, unverified = %v", names, unverified)
+	}
+}
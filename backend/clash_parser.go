@@ -0,0 +1,301 @@
+// backend/clash_parser.go
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// isClashYAML 判断内容是否为包含 proxies 列表的 Clash 配置
+func isClashYAML(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimRight(line, "\r "), "proxies:") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseClashConfig 读取 Clash/mihomo 配置中的 proxies 列表并转换为 ProxyNode 列表
+// 不支持的节点类型会被跳过，仅当没有任何可用节点时返回错误
+func ParseClashConfig(content string) ([]ProxyNode, error) {
+	var clashConfig struct {
+		Proxies []map[string]interface{} `yaml:"proxies"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &clashConfig); err != nil {
+		return nil, fmt.Errorf("Clash配置解析失败: %w", err)
+	}
+
+	var parsedNodes []ProxyNode
+	var errors []string
+
+	for i, proxy := range clashConfig.Proxies {
+		node, err := parseClashProxy(proxy)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("第%d个节点解析失败: %v", i+1, err))
+			continue
+		}
+		parsedNodes = append(parsedNodes, *node)
+	}
+
+	if len(parsedNodes) == 0 {
+		if len(errors) > 0 {
+			return nil, fmt.Errorf("解析失败: %s", strings.Join(errors, "; "))
+		}
+		return nil, fmt.Errorf("Clash配置中没有代理节点")
+	}
+
+	return parsedNodes, nil
+}
+
+// parseClashProxy 将单个 Clash 代理条目转换为 ProxyNode
+// 同时兼容 mihomo 的 *-opts 写法与旧版 Clash 的 ws-path/ws-headers 等键
+func parseClashProxy(proxy map[string]interface{}) (*ProxyNode, error) {
+	proxyType := yamlString(proxy, "type")
+	switch proxyType {
+	case "vmess", "vless", "ss", "trojan", "hysteria2", "tuic":
+	case "":
+		return nil, fmt.Errorf("缺少节点类型")
+	default:
+		return nil, fmt.Errorf("不支持的节点类型: %s", proxyType)
+	}
+
+	server := yamlString(proxy, "server")
+	if server == "" {
+		return nil, fmt.Errorf("缺少服务器地址")
+	}
+
+	port, err := interfaceToInt(proxy["port"])
+	if err != nil || port <= 0 {
+		return nil, fmt.Errorf("端口无效: %v", proxy["port"])
+	}
+
+	name := yamlString(proxy, "name")
+	if name == "" {
+		name = fmt.Sprintf("%s-%s:%d", proxyType, server, port)
+	}
+
+	node := &ProxyNode{
+		Name:           name,
+		Type:           proxyType,
+		Server:         server,
+		Port:           port,
+		UUID:           yamlString(proxy, "uuid"),
+		Password:       yamlString(proxy, "password"),
+		Cipher:         yamlString(proxy, "cipher"),
+		SkipCertVerify: yamlBool(proxy, "skip-cert-verify"),
+		Flow:           yamlString(proxy, "flow"),
+		UDP:            yamlBool(proxy, "udp"),
+		Fingerprint:    yamlString(proxy, "client-fingerprint"),
+		ALPN:           yamlStringSlice(proxy, "alpn"),
+	}
+
+	// trojan/hysteria2/tuic 使用 sni，vmess/vless 使用 servername
+	node.SNI = yamlString(proxy, "servername")
+	if node.SNI == "" {
+		node.SNI = yamlString(proxy, "sni")
+	}
+
+	switch proxyType {
+	case "vmess", "vless":
+		if proxyType == "vmess" {
+			node.AlterID, _ = interfaceToInt(proxy["alterId"])
+			if node.Cipher == "" {
+				node.Cipher = "auto"
+			}
+		} else {
+			node.Password = node.UUID
+		}
+		if node.UUID == "" {
+			return nil, fmt.Errorf("缺少UUID")
+		}
+		tlsEnabled := yamlBool(proxy, "tls")
+		node.TLS = &tlsEnabled
+		parseClashTransport(proxy, node)
+
+		if realityOpts := yamlMap(proxy, "reality-opts"); realityOpts != nil {
+			node.RealityOpts = &struct {
+				PublicKey string `yaml:"public-key"`
+				ShortID   string `yaml:"short-id,omitempty"`
+				SpiderX   string `yaml:"-"`
+			}{
+				PublicKey: yamlString(realityOpts, "public-key"),
+				ShortID:   yamlString(realityOpts, "short-id"),
+			}
+		}
+
+	case "ss":
+		if node.Cipher == "" || node.Password == "" {
+			return nil, fmt.Errorf("缺少加密方式或密码")
+		}
+		node.Plugin = yamlString(proxy, "plugin")
+		node.PluginOpts = yamlMap(proxy, "plugin-opts")
+
+	case "trojan":
+		if node.Password == "" {
+			return nil, fmt.Errorf("缺少密码")
+		}
+		tlsEnabled := true
+		node.TLS = &tlsEnabled
+		parseClashTransport(proxy, node)
+
+	case "hysteria2":
+		// 部分配置使用 auth 作为密码字段
+		if node.Password == "" {
+			node.Password = yamlString(proxy, "auth")
+		}
+		tlsEnabled := true
+		node.TLS = &tlsEnabled
+		node.Ports = yamlString(proxy, "ports")
+		node.Obfs = yamlString(proxy, "obfs")
+		node.ObfsPassword = yamlString(proxy, "obfs-password")
+		node.CertFingerprint = yamlString(proxy, "fingerprint")
+
+	case "tuic":
+		if node.UUID == "" || node.Password == "" {
+			return nil, fmt.Errorf("缺少UUID或密码")
+		}
+		tlsEnabled := true
+		node.TLS = &tlsEnabled
+		node.CongestionControl = yamlString(proxy, "congestion-controller")
+		node.UDPRelayMode = yamlString(proxy, "udp-relay-mode")
+	}
+
+	return node, nil
+}
+
+// parseClashTransport 解析 vmess/vless/trojan 的传输层配置
+func parseClashTransport(proxy map[string]interface{}, node *ProxyNode) {
+	network := yamlString(proxy, "network")
+	if network == "" {
+		network = "tcp"
+	}
+	node.Network = network
+
+	switch network {
+	case "ws":
+		path := yamlString(proxy, "ws-path")
+		headers := yamlStringMap(proxy, "ws-headers")
+		if wsOpts := yamlMap(proxy, "ws-opts"); wsOpts != nil {
+			path = yamlString(wsOpts, "path")
+			headers = yamlStringMap(wsOpts, "headers")
+		}
+		node.WSOpts = &struct {
+			Path    string            `yaml:"path"`
+			Headers map[string]string `yaml:"headers,omitempty"`
+		}{
+			Path:    path,
+			Headers: headers,
+		}
+
+	case "grpc":
+		serviceName := yamlString(proxy, "grpc-service-name")
+		if grpcOpts := yamlMap(proxy, "grpc-opts"); grpcOpts != nil {
+			serviceName = yamlString(grpcOpts, "grpc-service-name")
+		}
+		node.GRPCopts = &struct {
+			ServiceName string `yaml:"service-name,omitempty"`
+			Mode        string `yaml:"mode,omitempty"`
+		}{
+			ServiceName: serviceName,
+			Mode:        "gun",
+		}
+
+	case "h2", "http":
+		// h2-opts 的 host 与 http-opts 的 path 在 Clash 中均为列表，这里取第一个
+		method, path, host := "GET", "", ""
+		if h2Opts := yamlMap(proxy, "h2-opts"); h2Opts != nil {
+			path = yamlString(h2Opts, "path")
+			if hosts := yamlStringSlice(h2Opts, "host"); len(hosts) > 0 {
+				host = hosts[0]
+			}
+		}
+		if httpOpts := yamlMap(proxy, "http-opts"); httpOpts != nil {
+			if m := yamlString(httpOpts, "method"); m != "" {
+				method = m
+			}
+			if paths := yamlStringSlice(httpOpts, "path"); len(paths) > 0 {
+				path = paths[0]
+			}
+			if headers := yamlMap(httpOpts, "headers"); headers != nil {
+				if hosts := yamlStringSlice(headers, "Host"); len(hosts) > 0 {
+					host = hosts[0]
+				}
+			}
+		}
+		if host == "" {
+			host = node.Server
+		}
+		node.HTTPOpts = &struct {
+			Method  string            `yaml:"method,omitempty"`
+			Headers map[string]string `yaml:"headers,omitempty"`
+			Path    string            `yaml:"path,omitempty"`
+		}{
+			Method: method,
+			Path:   path,
+			Headers: map[string]string{
+				"Host": host,
+			},
+		}
+	}
+}
+
+// yamlString 读取字符串字段，数字等标量会被转换为字符串
+func yamlString(m map[string]interface{}, key string) string {
+	value, ok := m[key]
+	if !ok || value == nil {
+		return ""
+	}
+	return interfaceToString(value)
+}
+
+// yamlBool 读取布尔字段
+func yamlBool(m map[string]interface{}, key string) bool {
+	switch v := m[key].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true" || v == "1"
+	default:
+		return false
+	}
+}
+
+// yamlMap 读取嵌套的映射字段
+func yamlMap(m map[string]interface{}, key string) map[string]interface{} {
+	value, _ := m[key].(map[string]interface{})
+	return value
+}
+
+// yamlStringMap 读取值为字符串的映射字段 (如 headers)
+func yamlStringMap(m map[string]interface{}, key string) map[string]string {
+	nested := yamlMap(m, key)
+	if nested == nil {
+		return nil
+	}
+	result := make(map[string]string, len(nested))
+	for k := range nested {
+		result[k] = yamlString(nested, k)
+	}
+	return result
+}
+
+// yamlStringSlice 读取字符串列表字段，单个字符串视为只有一个元素的列表
+func yamlStringSlice(m map[string]interface{}, key string) []string {
+	switch v := m[key].(type) {
+	case []interface{}:
+		var items []string
+		for _, item := range v {
+			if s := interfaceToString(item); s != "" {
+				items = append(items, s)
+			}
+		}
+		return items
+	case string:
+		return splitList(v)
+	default:
+		return nil
+	}
+}
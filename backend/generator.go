@@ -2,21 +2,33 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
 )
 
 // GenerateRequest 生成订阅请求结构
 type GenerateRequest struct {
-	Links          string `json:"links"`
-	CheckNodes     bool   `json:"checkNodes"`
-	OnlyOnline     bool   `json:"onlyOnline"`
-	ConfigName     string `json:"configName"`
+	Links      string `json:"links"`
+	CheckNodes bool   `json:"checkNodes"`
+	OnlyOnline bool   `json:"onlyOnline"`
+	// 检测时通过节点访问的测试地址，为空时只检测连接
+	TestURL    string `json:"testUrl"`
+	ConfigName string `json:"configName"`
 	// 自定义配置选项
 	MixedPort      int    `json:"mixedPort"`
 	ControllerPort int    `json:"controllerPort"`
@@ -25,6 +37,23 @@ type GenerateRequest struct {
 	DNSMode        string `json:"dnsMode"`
 	EnableIPv6     bool   `json:"enableIPv6"`
 	CustomRules    string `json:"customRules"`
+	RegionGroups   bool   `json:"regionGroups"` // 按地区自动生成 url-test 代理组
+	// 节点过滤条件，在解析节点之后、检测与生成配置之前应用
+	NodeFilter NodeFilter `json:"nodeFilter"`
+	// 节点重命名，在检测之后、生成配置之前应用
+	Rename RenameOptions `json:"rename"`
+	// 节点排序方式：latency、region、name，为空时保持输入顺序
+	SortBy string `json:"sortBy"`
+	// 用户自定义代理组，排在内置代理组之后
+	ProxyGroups []ProxyGroupTemplate `json:"proxyGroups"`
+	// 规则模板 (default、minimal、china-direct、full)，仅 Clash 格式使用；规则集地址可按名称覆盖
+	RuleTemplate     string            `json:"ruleTemplate"`
+	RuleProviderURLs map[string]string `json:"ruleProviderUrls"`
+	// 输出格式：clash (默认)、clash-premium、sing-box、surge、quantumultx、loon
+	Format string `json:"format"`
+	// 远程订阅来源
+	SubscriptionURLs      []string `json:"subscriptionUrls"`
+	SubscriptionUserAgent string   `json:"subscriptionUserAgent"`
 }
 
 // GenerateResponse 生成订阅响应结构
@@ -32,9 +61,18 @@ type GenerateResponse struct {
 	Success         bool           `json:"success"`
 	Message         string         `json:"message"`
 	SubscriptionURL string         `json:"subscriptionUrl,omitempty"`
+	ShareLinkURL    string         `json:"shareLinkUrl,omitempty"` // Base64 分享链接订阅
 	NodeStatuses    []NodeStatus   `json:"nodeStatuses,omitempty"`
 	Summary         map[string]int `json:"summary,omitempty"`
 	ConfigContent   string         `json:"configContent,omitempty"`
+	SourceResults   []SourceResult `json:"sourceResults,omitempty"`
+	SkippedNodes    []string       `json:"skippedNodes,omitempty"`    // 目标格式无法表示的节点及原因
+	RuleErrors      []RuleError    `json:"ruleErrors,omitempty"`      // 自定义规则的逐行校验错误
+	ExcludedNodes   []ExcludedNode `json:"excludedNodes,omitempty"`   // 被过滤条件排除的节点及原因
+	MergedNodes     []MergedNode   `json:"mergedNodes,omitempty"`     // 与其他节点配置相同而被合并的节点
+	RenamedNodes    []RenamedNode  `json:"renamedNodes,omitempty"`    // 因名称冲突添加了后缀的节点
+	UnverifiedNodes []string       `json:"unverifiedNodes,omitempty"` // 仅包含在线节点时保留的无法验证的 UDP 节点
+	Warnings        []string       `json:"warnings,omitempty"`        // 生成选项未生效等提示
 }
 
 // GenerateSubscriptionHandler 处理生成订阅请求
@@ -58,40 +96,153 @@ func GenerateSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 验证输入
-	if strings.TrimSpace(req.Links) == "" {
+	subscriptionURLs := normalizeSubscriptionURLs(req.SubscriptionURLs)
+	if strings.TrimSpace(req.Links) == "" && len(subscriptionURLs) == 0 {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(GenerateResponse{
 			Success: false,
-			Message: "请提供代理链接",
+			Message: "请提供代理链接或订阅地址",
 		})
 		return
 	}
 
-	// 解析代理链接
-	nodes, err := ParseProxyLinks(req.Links)
-	if err != nil {
+	if len(subscriptionURLs) > subscriptionMaxURLs {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(GenerateResponse{
 			Success: false,
-			Message: fmt.Sprintf("解析代理链接失败: %v", err),
+			Message: fmt.Sprintf("订阅地址最多 %d 个", subscriptionMaxURLs),
+		})
+		return
+	}
+
+	if err := validateProxyGroupTemplates(req.ProxyGroups, req.RegionGroups); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GenerateResponse{
+			Success: false,
+			Message: fmt.Sprintf("自定义代理组无效: %v", err),
+		})
+		return
+	}
+	if _, err := compileNodeFilter(req.NodeFilter); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GenerateResponse{
+			Success: false,
+			Message: fmt.Sprintf("节点过滤条件无效: %v", err),
+		})
+		return
+	}
+	if _, err := compileRenameOptions(req.Rename); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GenerateResponse{
+			Success: false,
+			Message: fmt.Sprintf("节点重命名选项无效: %v", err),
+		})
+		return
+	}
+	if err := validateTestURL(req.TestURL, req.CheckNodes); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GenerateResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	if err := validateSortBy(req.SortBy, req.CheckNodes); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GenerateResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	if _, err := findRuleTemplate(req.RuleTemplate); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GenerateResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	if err := validateRuleProviderURLs(req.RuleProviderURLs); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GenerateResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	var nodes []ProxyNode
+	var sourceResults []SourceResult
+
+	// 解析代理链接
+	if strings.TrimSpace(req.Links) != "" {
+		linkNodes, err := ParseProxyLinks(req.Links)
+		if err != nil && len(subscriptionURLs) == 0 {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(GenerateResponse{
+				Success: false,
+				Message: fmt.Sprintf("解析代理链接失败: %v", err),
+			})
+			return
+		}
+
+		linkResult := SourceResult{Source: "links", Success: err == nil, NodeCount: len(linkNodes)}
+		if err != nil {
+			linkResult.Error = err.Error()
+		}
+		sourceResults = append(sourceResults, linkResult)
+		nodes = append(nodes, linkNodes...)
+	}
+
+	// 下载远程订阅
+	if len(subscriptionURLs) > 0 {
+		fetcher := NewSubscriptionFetcher(req.SubscriptionUserAgent)
+		fetchedNodes, fetchResults := fetcher.FetchAll(subscriptionURLs)
+		nodes = append(nodes, fetchedNodes...)
+		sourceResults = append(sourceResults, fetchResults...)
+	}
+
+	if len(nodes) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GenerateResponse{
+			Success:       false,
+			Message:       "未能从任何来源获取到节点",
+			SourceResults: sourceResults,
 		})
 		return
 	}
 
 	response := GenerateResponse{
-		Success: true,
-		Message: fmt.Sprintf("成功解析 %d 个节点", len(nodes)),
+		Success:       true,
+		Message:       fmt.Sprintf("成功解析 %d 个节点", len(nodes)),
+		SourceResults: sourceResults,
+	}
+
+	// 过滤节点，过滤条件已在前面校验过
+	parsedCount := len(nodes)
+	nodes, response.ExcludedNodes, _ = FilterNodes(nodes, req.NodeFilter)
+	if len(nodes) == 0 {
+		response.Success = false
+		response.Message = fmt.Sprintf("解析到的 %d 个节点均被过滤条件排除", parsedCount)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
 	}
 
+	// 合并配置相同的节点，多个订阅来源经常包含相同的服务器
+	// 去重在过滤之后进行，避免保留的节点被合并到随后被排除的同配置节点中
+	nodes, response.MergedNodes = DedupeNodes(nodes)
+
 	// 检查节点连通性
 	var finalNodes []ProxyNode
 	if req.CheckNodes {
-		statuses := CheckNodesConnectivity(nodes)
+		statuses := CheckNodesConnectivity(nodes, req.TestURL)
 		response.NodeStatuses = statuses
 		response.Summary = GetConnectivitySummary(statuses)
 
 		if req.OnlyOnline {
-			finalNodes = FilterOnlineNodes(statuses)
+			finalNodes, response.UnverifiedNodes = FilterOnlineNodes(statuses)
 			if len(finalNodes) == 0 {
 				response.Success = false
 				response.Message = "没有在线节点"
@@ -106,24 +257,57 @@ func GenerateSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
 		finalNodes = nodes
 	}
 
-	// 生成Clash配置
+	// 先排序再重命名，使 {index} 序号与排序结果一致
+	latencies := statusLatencies(response.NodeStatuses)
+	finalNodes = SortNodes(finalNodes, req.SortBy, latencies)
+
+	// 重命名节点，重命名选项已在前面校验过
+	finalNodes, _ = RenameNodes(finalNodes, req.Rename, latencies)
+
+	// Clash 等客户端不接受重名节点，也无法区分与代理组同名的节点，为这些节点添加后缀
+	finalNodes, response.RenamedNodes = DisambiguateNames(finalNodes, reservedGroupNames(req))
+
+	// 按名称排序时以重命名后的名称为准
+	if req.SortBy == sortByName {
+		finalNodes = SortNodes(finalNodes, sortByName, nil)
+	}
+
+	// 按输出格式生成配置
 	configName := req.ConfigName
 	if configName == "" {
 		configName = fmt.Sprintf("clash_config_%s_%d", user.Username, time.Now().Unix())
 	}
 
-	clashConfig := GenerateClashConfig(finalNodes, configName, req)
+	// 自定义规则有误时客户端会拒绝加载配置，逐行报告错误而不是生成无法使用的订阅
+	if ruleErrors := validateCustomRules(finalNodes, req); len(ruleErrors) > 0 {
+		response.Success = false
+		response.Message = fmt.Sprintf("自定义规则有 %d 处错误", len(ruleErrors))
+		response.RuleErrors = ruleErrors
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	configContent, fileExt, skippedNodes, err := generateConfigContent(finalNodes, configName, req)
+	response.SkippedNodes = skippedNodes
+	if err != nil {
+		response.Success = false
+		response.Message = fmt.Sprintf("生成配置失败: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	}
 
 	// 保存配置文件
-	filename := fmt.Sprintf("%s.yaml", configName)
+	filename := configName + fileExt
 	subscriptionDir := "../subscriptions"
 	// 在Docker环境中使用绝对路径
 	if _, err := os.Stat("/app"); err == nil {
 		subscriptionDir = "/app/subscriptions"
 	}
-	filepath := filepath.Join(subscriptionDir, filename)
+	filePath := filepath.Join(subscriptionDir, filename)
 
-	if err := os.WriteFile(filepath, []byte(clashConfig), 0644); err != nil {
+	if err := os.WriteFile(filePath, []byte(configContent), 0644); err != nil {
 		response.Success = false
 		response.Message = fmt.Sprintf("保存配置文件失败: %v", err)
 		w.Header().Set("Content-Type", "application/json")
@@ -134,13 +318,121 @@ func GenerateSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
 	// 生成订阅URL
 	subscriptionURL := fmt.Sprintf("http://%s/subscriptions/%s", r.Host, filename)
 	response.SubscriptionURL = subscriptionURL
-	response.ConfigContent = clashConfig
-	response.Message = fmt.Sprintf("成功生成包含 %d 个节点的配置", len(finalNodes))
+	response.ConfigContent = configContent
+	response.Message = fmt.Sprintf("成功生成包含 %d 个节点的配置", len(finalNodes)-len(skippedNodes))
+	if len(skippedNodes) > 0 {
+		response.Message += fmt.Sprintf("，%d 个节点因目标格式不支持被跳过", len(skippedNodes))
+	}
+	if len(response.MergedNodes) > 0 {
+		response.Message += fmt.Sprintf("，合并了 %d 个重复节点", len(response.MergedNodes))
+	}
+	if len(response.ExcludedNodes) > 0 {
+		response.Message += fmt.Sprintf("，%d 个节点被过滤条件排除", len(response.ExcludedNodes))
+	}
+	if len(response.UnverifiedNodes) > 0 {
+		response.Message += fmt.Sprintf("，保留了 %d 个无法验证的 UDP 节点", len(response.UnverifiedNodes))
+	}
+	if warning := ruleTemplateWarning(req); warning != "" {
+		response.Warnings = append(response.Warnings, warning)
+	}
+
+	// 同时发布 Base64 分享链接订阅，供 V2RayN、Shadowrocket 等客户端使用
+	shareLinkContent, skipped, err := GenerateShareLinkSubscription(finalNodes)
+	if err == nil {
+		shareLinkFilename := configName + ".txt"
+		if err := os.WriteFile(filepath.Join(subscriptionDir, shareLinkFilename), []byte(shareLinkContent), 0644); err == nil {
+			response.ShareLinkURL = fmt.Sprintf("http://%s/subscriptions/%s", r.Host, shareLinkFilename)
+		}
+	}
+	if len(skipped) > 0 {
+		response.Message += fmt.Sprintf("，%d 个节点无法转换为分享链接", len(skipped))
+	}
+
+	// 保存节点集，订阅接口会按客户端类型即时生成其他格式
+	// 保存失败时删除同名的旧节点集，避免订阅接口返回上一次生成的节点
+	if err := saveStoredSubscription(subscriptionDir, configName, finalNodes, req); err != nil {
+		log.Printf("保存节点集 %s 失败: %v", configName, err)
+		os.Remove(filepath.Join(subscriptionDir, configName+storedNodesSuffix))
+		response.Message += "，保存节点集失败，订阅链接只能返回当前格式"
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// subscriptionFileExts 生成的订阅文件可能使用的扩展名
+var subscriptionFileExts = map[string]bool{
+	".yaml": true,
+	".json": true,
+	".conf": true,
+	".txt":  true,
+}
+
+// formatNodes 返回目标格式实际输出的节点，跳过节点的逻辑与对应的生成器一致
+// 代理组由这些节点生成，校验自定义规则时需要使用同样的节点
+func formatNodes(nodes []ProxyNode, format string) []ProxyNode {
+	var build func(node ProxyNode) error
+	switch format {
+	case "clash":
+		build = func(node ProxyNode) error { _, err := buildMihomoProxy(node); return err }
+	case "clash-premium":
+		build = func(node ProxyNode) error { _, err := buildClashPremiumProxy(node); return err }
+	case "sing-box":
+		build = func(node ProxyNode) error { _, err := buildSingBoxOutbound(node); return err }
+	case "surge":
+		_, included, _ := buildProfileProxies(nodes, buildSurgeProxy)
+		return included
+	case "quantumultx":
+		_, included, _ := buildProfileProxies(nodes, buildQuantumultXServer)
+		return included
+	case "loon":
+		_, included, _ := buildProfileProxies(nodes, buildLoonProxy)
+		return included
+	default:
+		return nodes
+	}
+
+	var included []ProxyNode
+	for _, node := range nodes {
+		if build(node) == nil {
+			included = append(included, node)
+		}
+	}
+	return included
+}
+
+// generateConfigContent 按请求的输出格式生成配置内容，返回对应的文件扩展名以及目标格式无法表示而被跳过的节点
+func generateConfigContent(nodes []ProxyNode, configName string, req GenerateRequest) (string, string, []string, error) {
+	format, ok := normalizeFormat(req.Format)
+	if !ok {
+		return "", "", nil, fmt.Errorf("不支持的输出格式: %s", req.Format)
+	}
+
+	switch format {
+	case "sing-box":
+		content, skipped, err := GenerateSingBoxConfig(nodes, configName, req)
+		return content, ".json", skipped, err
+	case "surge":
+		content, skipped, err := GenerateSurgeConfig(nodes, configName, req)
+		return content, ".conf", skipped, err
+	case "quantumultx":
+		content, skipped, err := GenerateQuantumultXConfig(nodes, configName, req)
+		return content, ".conf", skipped, err
+	case "loon":
+		content, skipped, err := GenerateLoonConfig(nodes, configName, req)
+		return content, ".conf", skipped, err
+	case "base64":
+		content, skipped, err := GenerateShareLinkSubscription(nodes)
+		return content, ".txt", skipped, err
+	case "clash-premium":
+		content, skipped, err := GenerateClashPremiumConfig(nodes, configName, req)
+		return content, ".yaml", skipped, err
+	default:
+		content, skipped, err := GenerateClashConfig(nodes, configName, req)
+		return content, ".yaml", skipped, err
+	}
+}
+
 // ResetSubscriptionHandler 处理重置订阅请求
 func ResetSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -176,7 +468,7 @@ func ResetSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
 	userPrefix := fmt.Sprintf("clash_config_%s_", user.Username)
 
 	for _, file := range files {
-		if !file.IsDir() && strings.HasPrefix(file.Name(), userPrefix) && strings.HasSuffix(file.Name(), ".yaml") {
+		if !file.IsDir() && strings.HasPrefix(file.Name(), userPrefix) && subscriptionFileExts[filepath.Ext(file.Name())] {
 			filePath := filepath.Join(subscriptionDir, file.Name())
 			if err := os.Remove(filePath); err == nil {
 				deletedCount++
@@ -186,8 +478,8 @@ func ResetSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": fmt.Sprintf("已删除 %d 个订阅文件", deletedCount),
+		"success":       true,
+		"message":       fmt.Sprintf("已删除 %d 个订阅文件", deletedCount),
 		"deleted_count": deletedCount,
 	})
 }
@@ -228,25 +520,50 @@ func SaveConfigHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 简单的YAML格式验证
-	if !strings.Contains(req.ConfigContent, "proxies:") {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"message": "配置文件必须包含 proxies 部分",
-		})
-		return
-	}
-
 	// 确定文件名
 	filename := req.Filename
 	if filename == "" {
 		filename = fmt.Sprintf("clash_config_%s_%d.yaml", user.Username, time.Now().Unix())
 	}
 
-	// 确保文件名以.yaml结尾
-	if !strings.HasSuffix(filename, ".yaml") && !strings.HasSuffix(filename, ".yml") {
-		filename += ".yaml"
+	if strings.HasSuffix(filename, ".json") {
+		// sing-box 配置：简单的JSON格式验证
+		var singBoxConfig struct {
+			Outbounds []json.RawMessage `json:"outbounds"`
+		}
+		if err := json.Unmarshal([]byte(req.ConfigContent), &singBoxConfig); err != nil || len(singBoxConfig.Outbounds) == 0 {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": "配置文件必须是包含 outbounds 部分的JSON",
+			})
+			return
+		}
+	} else if strings.HasSuffix(filename, ".conf") {
+		// Surge/Loon/Quantumult X 配置：检查是否包含节点段
+		if !strings.Contains(req.ConfigContent, "[Proxy]") && !strings.Contains(req.ConfigContent, "[server_local]") {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": "配置文件必须包含 [Proxy] 或 [server_local] 部分",
+			})
+			return
+		}
+	} else {
+		// 简单的YAML格式验证
+		if !strings.Contains(req.ConfigContent, "proxies:") {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": "配置文件必须包含 proxies 部分",
+			})
+			return
+		}
+
+		// 确保文件名以.yaml结尾
+		if !strings.HasSuffix(filename, ".yaml") && !strings.HasSuffix(filename, ".yml") {
+			filename += ".yaml"
+		}
 	}
 
 	// 确定保存路径
@@ -254,7 +571,7 @@ func SaveConfigHandler(w http.ResponseWriter, r *http.Request) {
 	if _, err := os.Stat("/app"); err == nil {
 		subscriptionDir = "/app/subscriptions"
 	}
-	
+
 	filePath := filepath.Join(subscriptionDir, filename)
 
 	// 保存文件
@@ -279,188 +596,635 @@ func SaveConfigHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GenerateClashConfig 生成Clash配置文件
-func GenerateClashConfig(nodes []ProxyNode, configName string, config GenerateRequest) string {
-	var configBuilder strings.Builder
-	
+// ClashConfig Clash 配置文件结构，字段顺序即输出顺序
+type ClashConfig struct {
+	MixedPort          int                          `yaml:"mixed-port"`
+	AllowLan           bool                         `yaml:"allow-lan"`
+	BindAddress        string                       `yaml:"bind-address"`
+	Mode               string                       `yaml:"mode"`
+	LogLevel           string                       `yaml:"log-level"`
+	ExternalController string                       `yaml:"external-controller"`
+	DNS                ClashDNS                     `yaml:"dns"`
+	Proxies            []ClashProxy                 `yaml:"proxies"`
+	ProxyGroups        []ClashProxyGroup            `yaml:"proxy-groups"`
+	RuleProviders      map[string]ClashRuleProvider `yaml:"rule-providers,omitempty"`
+	Rules              []string                     `yaml:"rules"`
+}
+
+// ClashDNS Clash DNS 配置
+type ClashDNS struct {
+	Enable            bool     `yaml:"enable"`
+	IPv6              bool     `yaml:"ipv6"`
+	DefaultNameserver []string `yaml:"default-nameserver"`
+	EnhancedMode      string   `yaml:"enhanced-mode"`
+	FakeIPRange       string   `yaml:"fake-ip-range"`
+	UseHosts          bool     `yaml:"use-hosts"`
+	Nameserver        []string `yaml:"nameserver"`
+}
+
+// ClashProxy Clash 配置中的单个代理节点
+type ClashProxy struct {
+	Name                 string                 `yaml:"name"`
+	Type                 string                 `yaml:"type"`
+	Server               string                 `yaml:"server"`
+	Port                 int                    `yaml:"port"`
+	Ports                string                 `yaml:"ports,omitempty"`
+	UUID                 string                 `yaml:"uuid,omitempty"`
+	AlterID              *int                   `yaml:"alterId,omitempty"`
+	Cipher               string                 `yaml:"cipher,omitempty"`
+	Password             string                 `yaml:"password,omitempty"`
+	Plugin               string                 `yaml:"plugin,omitempty"`
+	PluginOpts           map[string]interface{} `yaml:"plugin-opts,omitempty"`
+	Network              string                 `yaml:"network,omitempty"`
+	WSPath               string                 `yaml:"ws-path,omitempty"`
+	WSHeaders            map[string]string      `yaml:"ws-headers,omitempty"`
+	GRPCServiceName      string                 `yaml:"grpc-service-name,omitempty"`
+	WSOpts               *ClashWSOpts           `yaml:"ws-opts,omitempty"`
+	H2Opts               *ClashH2Opts           `yaml:"h2-opts,omitempty"`
+	HTTPOpts             *ClashHTTPOpts         `yaml:"http-opts,omitempty"`
+	GRPCOpts             *ClashGRPCOpts         `yaml:"grpc-opts,omitempty"`
+	TLS                  bool                   `yaml:"tls,omitempty"`
+	ServerName           string                 `yaml:"servername,omitempty"`
+	SNI                  string                 `yaml:"sni,omitempty"`
+	SkipCertVerify       bool                   `yaml:"skip-cert-verify,omitempty"`
+	ALPN                 []string               `yaml:"alpn,omitempty"`
+	ClientFingerprint    string                 `yaml:"client-fingerprint,omitempty"`
+	RealityOpts          *ClashRealityOpts      `yaml:"reality-opts,omitempty"`
+	Obfs                 string                 `yaml:"obfs,omitempty"`
+	ObfsPassword         string                 `yaml:"obfs-password,omitempty"`
+	Fingerprint          string                 `yaml:"fingerprint,omitempty"`
+	CongestionController string                 `yaml:"congestion-controller,omitempty"`
+	UDPRelayMode         string                 `yaml:"udp-relay-mode,omitempty"`
+	Flow                 string                 `yaml:"flow,omitempty"`
+	PacketEncoding       string                 `yaml:"packet-encoding,omitempty"`
+	UDP                  bool                   `yaml:"udp"`
+}
+
+// ClashWSOpts ws 传输选项
+type ClashWSOpts struct {
+	Path    string            `yaml:"path"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+// ClashH2Opts h2 传输选项
+type ClashH2Opts struct {
+	Host []string `yaml:"host,omitempty"`
+	Path string   `yaml:"path,omitempty"`
+}
+
+// ClashHTTPOpts http 传输选项 (HTTP/1.1 伪装)
+type ClashHTTPOpts struct {
+	Method  string              `yaml:"method,omitempty"`
+	Path    []string            `yaml:"path,omitempty"`
+	Headers map[string][]string `yaml:"headers,omitempty"`
+}
+
+// ClashGRPCOpts grpc 传输选项
+type ClashGRPCOpts struct {
+	GRPCServiceName string `yaml:"grpc-service-name"`
+}
+
+// ClashRealityOpts Reality 选项
+type ClashRealityOpts struct {
+	PublicKey string `yaml:"public-key"`
+	ShortID   string `yaml:"short-id,omitempty"`
+}
+
+// ClashProxyGroup Clash 代理组
+type ClashProxyGroup struct {
+	Name      string   `yaml:"name"`
+	Type      string   `yaml:"type"`
+	Strategy  string   `yaml:"strategy,omitempty"`
+	URL       string   `yaml:"url,omitempty"`
+	Interval  int      `yaml:"interval,omitempty"`
+	Tolerance int      `yaml:"tolerance,omitempty"`
+	Proxies   []string `yaml:"proxies"`
+}
+
+// 默认代理组名称，各输出格式共用
+const (
+	groupNodeSelect = "🚀 节点选择"
+	groupAutoSelect = "♻️ 自动选择"
+	groupDirect     = "🎯 全球直连"
+)
+
+// 自动选择代理组的测速地址与参数 (间隔单位为秒)
+const (
+	proxyTestURL       = "http://www.gstatic.com/generate_204"
+	proxyTestInterval  = 300
+	proxyTestTolerance = 50
+)
+
+// GenerateClashConfig 生成使用 mihomo (Clash Meta) 语法的Clash配置文件
+// 无法表示的节点 (如 gRPC multi 模式) 会被跳过并返回
+func GenerateClashConfig(nodes []ProxyNode, configName string, config GenerateRequest) (string, []string, error) {
+	return generateClashConfig(nodes, configName, config, false)
+}
+
+// GenerateClashPremiumConfig 生成兼容旧版 Clash Premium 内核的配置文件
+// Clash Premium 不支持 VLESS、Hysteria2 与 TUIC，这些节点会被跳过并返回
+func GenerateClashPremiumConfig(nodes []ProxyNode, configName string, config GenerateRequest) (string, []string, error) {
+	return generateClashConfig(nodes, configName, config, true)
+}
+
+// generateClashConfig 生成Clash配置文件，premium 为 true 时使用旧版 Clash Premium 语法
+func generateClashConfig(nodes []ProxyNode, configName string, config GenerateRequest, premium bool) (string, []string, error) {
 	// 设置默认值
 	mixedPort := config.MixedPort
 	if mixedPort == 0 {
 		mixedPort = 7890
 	}
-	
+
 	controllerPort := config.ControllerPort
 	if controllerPort == 0 {
 		controllerPort = 9090
 	}
-	
+
 	logLevel := config.LogLevel
 	if logLevel == "" {
 		logLevel = "info"
 	}
-	
+
 	dnsMode := config.DNSMode
 	if dnsMode == "" {
 		dnsMode = "fake-ip"
 	}
-	
-	// 基础配置
-	configBuilder.WriteString(fmt.Sprintf(`# Clash配置文件 - %s
-# 生成时间: %s
-# ClashLink 自动生成
-
-# 基础配置
-mixed-port: %d
-allow-lan: %t
-bind-address: '*'
-mode: rule
-log-level: %s
-external-controller: '127.0.0.1:%d'
-
-# DNS 配置
-dns:
-  enable: true
-  ipv6: %t
-  default-nameserver:
-    - 223.5.5.5
-    - 114.114.114.114
-    - 8.8.8.8
-  enhanced-mode: %s
-  fake-ip-range: 198.18.0.1/16
-  use-hosts: true
-  nameserver:
-    - https://doh.pub/dns-query
-    - https://dns.alidns.com/dns-query
-    - https://cloudflare-dns.com/dns-query
-
-# 代理节点
-proxies:
-`, configName, time.Now().Format("2006-01-02 15:04:05"), mixedPort, config.AllowLan, logLevel, controllerPort, config.EnableIPv6, dnsMode))
+
+	clashConfig := ClashConfig{
+		MixedPort:          mixedPort,
+		AllowLan:           config.AllowLan,
+		BindAddress:        "*",
+		Mode:               "rule",
+		LogLevel:           logLevel,
+		ExternalController: fmt.Sprintf("127.0.0.1:%d", controllerPort),
+		DNS: ClashDNS{
+			Enable:            true,
+			IPv6:              config.EnableIPv6,
+			DefaultNameserver: []string{"223.5.5.5", "114.114.114.114", "8.8.8.8"},
+			EnhancedMode:      dnsMode,
+			FakeIPRange:       "198.18.0.1/16",
+			UseHosts:          true,
+			Nameserver: []string{
+				"https://doh.pub/dns-query",
+				"https://dns.alidns.com/dns-query",
+				"https://cloudflare-dns.com/dns-query",
+			},
+		},
+	}
 
 	// 代理节点配置
-	proxyNames := make([]string, 0, len(nodes))
+	includedNodes := make([]ProxyNode, 0, len(nodes))
+	var skipped []string
+	buildProxy, clientName := buildMihomoProxy, "Clash"
+	if premium {
+		buildProxy, clientName = buildClashPremiumProxy, "Clash Premium"
+	}
 	for _, node := range nodes {
-		proxyNames = append(proxyNames, node.Name)
-		configBuilder.WriteString(generateProxyConfig(node))
+		proxy, err := buildProxy(node)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", node.Name, err))
+			continue
+		}
+		clashConfig.Proxies = append(clashConfig.Proxies, proxy)
+		includedNodes = append(includedNodes, node)
+	}
+	if len(includedNodes) == 0 {
+		return "", skipped, fmt.Errorf("没有 %s 支持的节点", clientName)
+	}
+
+	ruleTemplate, err := findRuleTemplate(config.RuleTemplate)
+	if err != nil {
+		return "", skipped, err
+	}
+	if err := validateRuleProviderURLs(config.RuleProviderURLs); err != nil {
+		return "", skipped, err
 	}
 
 	// 代理组配置
-	configBuilder.WriteString("\n# 代理组\nproxy-groups:\n")
-	configBuilder.WriteString("  - name: \"🚀 节点选择\"\n")
-	configBuilder.WriteString("    type: select\n")
-	configBuilder.WriteString("    proxies:\n")
-	configBuilder.WriteString("      - \"♻️ 自动选择\"\n")
-	configBuilder.WriteString("      - \"🎯 全球直连\"\n")
-	for _, name := range proxyNames {
-		configBuilder.WriteString(fmt.Sprintf("      - \"%s\"\n", name))
-	}
-
-	configBuilder.WriteString("  - name: \"♻️ 自动选择\"\n")
-	configBuilder.WriteString("    type: url-test\n")
-	configBuilder.WriteString("    url: http://www.gstatic.com/generate_204\n")
-	configBuilder.WriteString("    interval: 300\n")
-	configBuilder.WriteString("    tolerance: 50\n")
-	configBuilder.WriteString("    proxies:\n")
-	for _, name := range proxyNames {
-		configBuilder.WriteString(fmt.Sprintf("      - \"%s\"\n", name))
-	}
-
-	configBuilder.WriteString("  - name: \"🎯 全球直连\"\n")
-	configBuilder.WriteString("    type: select\n")
-	configBuilder.WriteString("    proxies:\n")
-	configBuilder.WriteString("      - \"DIRECT\"\n")
-	configBuilder.WriteString("      - \"🚀 节点选择\"\n")
-
-	// 规则配置
-	configBuilder.WriteString(`
-# 分流规则
-rules:
-  - DOMAIN-SUFFIX,local,DIRECT
-  - IP-CIDR,127.0.0.0/8,DIRECT
-  - IP-CIDR,172.16.0.0/12,DIRECT
-  - IP-CIDR,192.168.0.0/16,DIRECT
-  - IP-CIDR,10.0.0.0/8,DIRECT
-  - IP-CIDR,17.0.0.0/8,DIRECT
-  - IP-CIDR,100.64.0.0/10,DIRECT
-  - DOMAIN-SUFFIX,cn,🎯 全球直连
-  - GEOIP,CN,🎯 全球直连`)
-
-	// 添加自定义规则
-	if config.CustomRules != "" {
-		configBuilder.WriteString("\n  # 自定义规则\n")
-		// 按行分割自定义规则
-		rules := strings.Split(strings.TrimSpace(config.CustomRules), "\n")
-		for _, rule := range rules {
-			rule = strings.TrimSpace(rule)
-			if rule != "" && !strings.HasPrefix(rule, "#") {
-				configBuilder.WriteString(fmt.Sprintf("  %s\n", rule))
+	proxyGroups, err := buildClashProxyGroups(includedNodes, config, ruleTemplate)
+	if err != nil {
+		return "", skipped, err
+	}
+	for _, group := range proxyGroups {
+		clashGroup := ClashProxyGroup{
+			Name:      group.Name,
+			Type:      group.Type,
+			Strategy:  group.Strategy,
+			URL:       group.URL,
+			Interval:  group.Interval,
+			Tolerance: group.Tolerance,
+			Proxies:   group.Proxies,
+		}
+		// Clash Premium 不支持 sticky-sessions 策略
+		if premium && clashGroup.Strategy == strategyStickySessions {
+			clashGroup.Strategy = strategyConsistentHashing
+		}
+		clashConfig.ProxyGroups = append(clashConfig.ProxyGroups, clashGroup)
+	}
+
+	// 规则配置：内置规则之后追加自定义规则；使用规则集模板时自定义规则排在最前，以便覆盖规则集
+	customRules := splitCustomRules(config.CustomRules)
+	clashConfig.RuleProviders = ruleTemplate.buildRuleProviders(config.RuleProviderURLs)
+	if clashConfig.RuleProviders == nil {
+		clashConfig.Rules = append(append([]string{}, ruleTemplate.Rules...), customRules...)
+	} else {
+		clashConfig.Rules = append(customRules, ruleTemplate.Rules...)
+	}
+
+	clashConfig.Rules = append(clashConfig.Rules, "MATCH,"+groupNodeSelect)
+
+	body, err := marshalYAML(clashConfig)
+	if err != nil {
+		return "", skipped, err
+	}
+
+	var configBuilder strings.Builder
+	configBuilder.WriteString(fmt.Sprintf("# Clash配置文件 - %s\n", strings.ReplaceAll(configName, "\n", " ")))
+	configBuilder.WriteString(fmt.Sprintf("# 生成时间: %s\n", time.Now().Format("2006-01-02 15:04:05")))
+	configBuilder.WriteString("# ClashLink 自动生成\n\n")
+	configBuilder.WriteString(body)
+
+	return configBuilder.String(), skipped, nil
+}
+
+// defaultRules 返回各输出格式共用的默认规则 (Clash 语法，不含最后的 MATCH 规则)
+func defaultRules() []string {
+	return []string{
+		"DOMAIN-SUFFIX,local,DIRECT",
+		"IP-CIDR,127.0.0.0/8,DIRECT",
+		"IP-CIDR,172.16.0.0/12,DIRECT",
+		"IP-CIDR,192.168.0.0/16,DIRECT",
+		"IP-CIDR,10.0.0.0/8,DIRECT",
+		"IP-CIDR,17.0.0.0/8,DIRECT",
+		"IP-CIDR,100.64.0.0/10,DIRECT",
+		"DOMAIN-SUFFIX,cn," + groupDirect,
+		"GEOIP,CN," + groupDirect,
+	}
+}
+
+// marshalYAML 以两格缩进序列化 YAML
+// yaml.v3 会把 emoji 等 BMP 以外的字符转义为 \UXXXXXXXX，不便于用户阅读和编辑；
+// 这里只在双引号标量内将其还原为原字符，并通过反序列化比对确保还原前后语义一致
+func marshalYAML(value interface{}) (string, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return "", fmt.Errorf("生成YAML失败: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("生成YAML失败: %w", err)
+	}
+
+	escaped := buf.String()
+	if !strings.Contains(escaped, `\U`) {
+		return escaped, nil
+	}
+
+	// 通过节点树定位所有双引号标量，单引号与普通标量中的反斜杠不是转义符，不能改动
+	var document yaml.Node
+	if err := yaml.Unmarshal(buf.Bytes(), &document); err != nil {
+		return escaped, nil
+	}
+	lines := strings.SplitAfter(escaped, "\n")
+	lineOffsets := make([]int, len(lines))
+	for i := 1; i < len(lines); i++ {
+		lineOffsets[i] = lineOffsets[i-1] + len(lines[i-1])
+	}
+	var quoted []int
+	var collect func(node *yaml.Node)
+	collect = func(node *yaml.Node) {
+		if node.Kind == yaml.ScalarNode && node.Style&yaml.DoubleQuotedStyle != 0 && node.Line >= 1 && node.Line <= len(lines) {
+			// Column 按字符计数，需要换算为字节偏移
+			line := lines[node.Line-1]
+			column := 0
+			for i := 1; i < node.Column && column < len(line); i++ {
+				_, size := utf8.DecodeRuneInString(line[column:])
+				column += size
+			}
+			if offset := lineOffsets[node.Line-1] + column; offset < len(escaped) && escaped[offset] == '"' {
+				quoted = append(quoted, offset)
+			}
+		}
+		for _, child := range node.Content {
+			collect(child)
+		}
+	}
+	collect(&document)
+	sort.Ints(quoted)
+
+	var restoredBuilder strings.Builder
+	position := 0
+	for _, offset := range quoted {
+		if offset < position {
+			continue
+		}
+		restoredBuilder.WriteString(escaped[position:offset])
+		position = offset + unescapeYAMLRunes(&restoredBuilder, escaped[offset:])
+	}
+	restoredBuilder.WriteString(escaped[position:])
+	unescaped := restoredBuilder.String()
+	if unescaped == escaped {
+		return escaped, nil
+	}
+
+	var original, restored interface{}
+	if yaml.Unmarshal([]byte(escaped), &original) != nil ||
+		yaml.Unmarshal([]byte(unescaped), &restored) != nil ||
+		!reflect.DeepEqual(original, restored) {
+		return escaped, nil
+	}
+	return unescaped, nil
+}
+
+// unescapeYAMLRunes 将以双引号开头的标量写入 builder，其中可打印字符的 \UXXXXXXXX 转义还原为原字符，
+// 其余转义序列保持原样，返回标量 (含两侧引号) 占用的字节数
+func unescapeYAMLRunes(builder *strings.Builder, scalar string) int {
+	builder.WriteByte('"')
+	for i := 1; i < len(scalar); i++ {
+		switch scalar[i] {
+		case '"':
+			builder.WriteByte('"')
+			return i + 1
+		case '\\':
+			if i+10 <= len(scalar) && scalar[i+1] == 'U' {
+				if code, err := strconv.ParseUint(scalar[i+2:i+10], 16, 32); err == nil && utf8.ValidRune(rune(code)) && unicode.IsPrint(rune(code)) {
+					builder.WriteRune(rune(code))
+					i += 9
+					continue
+				}
 			}
+			// 其余转义序列原样保留，跳过被转义的字符以免把 \" 误认为结束引号
+			builder.WriteByte('\\')
+			if i+1 < len(scalar) {
+				i++
+				builder.WriteByte(scalar[i])
+			}
+		default:
+			builder.WriteByte(scalar[i])
 		}
 	}
+	return len(scalar)
+}
 
-	configBuilder.WriteString(`
-  - MATCH,🚀 节点选择
-`)
+// splitCustomRules 按行拆分自定义规则，忽略空行与注释，兼容带 "- " 前缀的 YAML 列表写法
+func splitCustomRules(customRules string) []string {
+	var rules []string
+	for _, line := range customRuleLines(customRules) {
+		rules = append(rules, line.Rule)
+	}
+	return rules
+}
 
-	return configBuilder.String()
+// customRuleLine 自定义规则及其在输入中的行号 (从 1 开始)
+type customRuleLine struct {
+	Line int
+	Rule string
 }
 
-// generateProxyConfig 生成单个代理配置
-func generateProxyConfig(node ProxyNode) string {
-	var config strings.Builder
+// customRuleLines 拆分自定义规则，忽略空行与 # 注释，并去除 YAML 列表前缀 "- "
+func customRuleLines(customRules string) []customRuleLine {
+	var lines []customRuleLine
+	for i, rule := range strings.Split(customRules, "\n") {
+		rule = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rule), "- "))
+		if rule != "" && !strings.HasPrefix(rule, "#") {
+			lines = append(lines, customRuleLine{Line: i + 1, Rule: rule})
+		}
+	}
+	return lines
+}
 
-	config.WriteString(fmt.Sprintf("  - name: \"%s\"\n", node.Name))
-	config.WriteString(fmt.Sprintf("    type: %s\n", node.Type))
-	config.WriteString(fmt.Sprintf("    server: %s\n", node.Server))
-	config.WriteString(fmt.Sprintf("    port: %d\n", node.Port))
+// buildClashProxyGroups 构建 Clash 配置的代理组，规则模板需要的代理组排在最后
+func buildClashProxyGroups(nodes []ProxyNode, config GenerateRequest, ruleTemplate *RuleTemplate) ([]ProxyGroup, error) {
+	proxyGroups, err := buildProxyGroups(nodes, config)
+	if err != nil {
+		return nil, err
+	}
 
-	if node.UUID != "" {
-		config.WriteString(fmt.Sprintf("    uuid: %s\n", node.UUID))
+	var regionGroupNames []string
+	if config.RegionGroups {
+		for _, group := range buildRegionGroups(proxyNamesOf(nodes)) {
+			regionGroupNames = append(regionGroupNames, group.Name)
+		}
 	}
+	proxyGroups = append(proxyGroups, ruleTemplate.buildGroups(regionGroupNames)...)
+	if err := checkNodeNameConflicts(nodes, proxyGroups); err != nil {
+		return nil, err
+	}
+	return proxyGroups, nil
+}
 
-	if node.Type == "vmess" {
-		config.WriteString(fmt.Sprintf("    alterId: %d\n", node.AlterID))
-		config.WriteString(fmt.Sprintf("    cipher: %s\n", node.Cipher))
+// buildMihomoProxy 将 ProxyNode 转换为 mihomo (Clash Meta) 语法的代理节点
+// 传输层统一使用 ws-opts/h2-opts/http-opts/grpc-opts，不再输出已废弃的 ws-path 等键
+func buildMihomoProxy(node ProxyNode) (ClashProxy, error) {
+	proxy := ClashProxy{
+		Name:   node.Name,
+		Type:   node.Type,
+		Server: node.Server,
+		Port:   node.Port,
+		UUID:   node.UUID,
+		UDP:    true,
 	}
 
-		if node.Network != "" && node.Network != "tcp" {
-		config.WriteString(fmt.Sprintf("    network: %s\n", node.Network))
-		
-		if node.Network == "ws" && node.WSOpts != nil {
-			if node.WSOpts.Path != "" {
-				config.WriteString(fmt.Sprintf("    ws-path: %s\n", node.WSOpts.Path))
+	switch node.Type {
+	case "vmess", "vless":
+		if node.Type == "vmess" {
+			alterID := node.AlterID
+			proxy.AlterID = &alterID
+			proxy.Cipher = node.Cipher
+		}
+		if err := setMihomoTransport(&proxy, node); err != nil {
+			return proxy, err
+		}
+
+		if node.TLS != nil && *node.TLS {
+			proxy.TLS = true
+			proxy.ServerName = node.SNI
+			proxy.SkipCertVerify = node.SkipCertVerify
+			proxy.ALPN = node.ALPN
+			proxy.ClientFingerprint = node.Fingerprint
+			if node.RealityOpts != nil {
+				proxy.RealityOpts = &ClashRealityOpts{
+					PublicKey: node.RealityOpts.PublicKey,
+					ShortID:   node.RealityOpts.ShortID,
+				}
 			}
-			if node.WSOpts.Headers != nil && node.WSOpts.Headers["Host"] != "" {
-				config.WriteString(fmt.Sprintf("    ws-headers:\n      Host: %s\n", node.WSOpts.Headers["Host"]))
+		}
+
+		proxy.Flow = node.Flow
+		// 使用 XUDP 转发 UDP，避免每个 UDP 会话单独建立连接
+		proxy.PacketEncoding = "xudp"
+
+	case "ss":
+		proxy.Cipher = node.Cipher
+		proxy.Password = node.Password
+		proxy.Plugin = node.Plugin
+		if node.Plugin != "" {
+			proxy.PluginOpts = node.PluginOpts
+		}
+
+	case "trojan":
+		proxy.Password = node.Password
+		proxy.SNI = node.SNI
+		proxy.ALPN = node.ALPN
+		proxy.SkipCertVerify = node.SkipCertVerify
+		proxy.ClientFingerprint = node.Fingerprint
+		if err := setMihomoTransport(&proxy, node); err != nil {
+			return proxy, err
+		}
+
+	case "hysteria2":
+		proxy.Ports = node.Ports
+		proxy.Password = node.Password
+		proxy.SNI = node.SNI
+		proxy.SkipCertVerify = node.SkipCertVerify
+		if node.Obfs != "" {
+			proxy.Obfs = node.Obfs
+			proxy.ObfsPassword = node.ObfsPassword
+		}
+		proxy.Fingerprint = node.CertFingerprint
+		proxy.ALPN = node.ALPN
+
+	case "tuic":
+		proxy.Password = node.Password
+		proxy.SNI = node.SNI
+		proxy.SkipCertVerify = node.SkipCertVerify
+		proxy.ALPN = node.ALPN
+		proxy.CongestionController = node.CongestionControl
+		proxy.UDPRelayMode = node.UDPRelayMode
+	}
+
+	return proxy, nil
+}
+
+// errGRPCMultiMode mihomo 与 Clash Premium 的 grpc-opts 只有 grpc-service-name，只支持 gun 模式
+var errGRPCMultiMode = errors.New("不支持 gRPC multi 模式")
+
+// checkGRPCMode 检查 gRPC 节点能否以 gun 模式输出，multi 模式的节点无法表示
+func checkGRPCMode(node ProxyNode) error {
+	if node.GRPCopts != nil && node.GRPCopts.Mode == "multi" {
+		return errGRPCMultiMode
+	}
+	return nil
+}
+
+// setMihomoTransport 写入 vmess/vless/trojan 的传输层选项，无法表示的传输选项返回错误
+func setMihomoTransport(proxy *ClashProxy, node ProxyNode) error {
+	switch node.Network {
+	case "ws":
+		proxy.Network = "ws"
+		if node.WSOpts != nil {
+			proxy.WSOpts = &ClashWSOpts{Path: node.WSOpts.Path}
+			if host := node.WSOpts.Headers["Host"]; host != "" {
+				proxy.WSOpts.Headers = map[string]string{"Host": host}
+			}
+		}
+	case "grpc":
+		if err := checkGRPCMode(node); err != nil {
+			return err
+		}
+		proxy.Network = "grpc"
+		if node.GRPCopts != nil {
+			proxy.GRPCOpts = &ClashGRPCOpts{GRPCServiceName: node.GRPCopts.ServiceName}
+		}
+	case "h2":
+		proxy.Network = "h2"
+		if node.HTTPOpts != nil {
+			proxy.H2Opts = &ClashH2Opts{Path: node.HTTPOpts.Path}
+			if host := node.HTTPOpts.Headers["Host"]; host != "" {
+				proxy.H2Opts.Host = []string{host}
 			}
-		} else if node.Network == "grpc" && node.GRPCopts != nil && node.GRPCopts.ServiceName != "" {
-			config.WriteString(fmt.Sprintf("    grpc-service-name: %s\n", node.GRPCopts.ServiceName))
-		} else if (node.Network == "h2" || node.Network == "http") && node.HTTPOpts != nil {
+		}
+	case "http":
+		proxy.Network = "http"
+		if node.HTTPOpts != nil {
+			proxy.HTTPOpts = &ClashHTTPOpts{Method: node.HTTPOpts.Method}
 			if node.HTTPOpts.Path != "" {
-				config.WriteString(fmt.Sprintf("    h2-opts:\n      path: %s\n", node.HTTPOpts.Path))
+				proxy.HTTPOpts.Path = []string{node.HTTPOpts.Path}
 			}
-			if node.HTTPOpts.Headers != nil && node.HTTPOpts.Headers["Host"] != "" {
-				config.WriteString(fmt.Sprintf("      host: %s\n", node.HTTPOpts.Headers["Host"]))
+			if host := node.HTTPOpts.Headers["Host"]; host != "" {
+				proxy.HTTPOpts.Headers = map[string][]string{"Host": {host}}
 			}
 		}
 	}
-	
-	if node.TLS != nil && *node.TLS {
-		config.WriteString("    tls: true\n")
-		if node.SNI != "" {
-			config.WriteString(fmt.Sprintf("    servername: %s\n", node.SNI))
+	return nil
+}
+
+// buildClashPremiumProxy 将 ProxyNode 转换为旧版 Clash Premium 语法的代理节点
+// vmess 的 ws/grpc 选项使用 ws-path、ws-headers 与 grpc-service-name 等旧键
+func buildClashPremiumProxy(node ProxyNode) (ClashProxy, error) {
+	proxy := ClashProxy{
+		Name:   node.Name,
+		Type:   node.Type,
+		Server: node.Server,
+		Port:   node.Port,
+		UUID:   node.UUID,
+		UDP:    true,
+	}
+
+	switch node.Type {
+	case "vmess":
+		alterID := node.AlterID
+		proxy.AlterID = &alterID
+		proxy.Cipher = node.Cipher
+
+		switch node.Network {
+		case "", "tcp":
+		case "ws":
+			proxy.Network = "ws"
+			if node.WSOpts != nil {
+				proxy.WSPath = node.WSOpts.Path
+				if host := node.WSOpts.Headers["Host"]; host != "" {
+					proxy.WSHeaders = map[string]string{"Host": host}
+				}
+			}
+		case "grpc":
+			if err := checkGRPCMode(node); err != nil {
+				return proxy, err
+			}
+			proxy.Network = "grpc"
+			if node.GRPCopts != nil {
+				proxy.GRPCServiceName = node.GRPCopts.ServiceName
+			}
+		case "h2", "http":
+			if err := setMihomoTransport(&proxy, node); err != nil {
+				return proxy, err
+			}
+		default:
+			return proxy, fmt.Errorf("Clash Premium 不支持 %s 传输", node.Network)
 		}
-		if node.SkipCertVerify {
-			config.WriteString("    skip-cert-verify: true\n")
+
+		if node.TLS != nil && *node.TLS {
+			proxy.TLS = true
+			proxy.ServerName = node.SNI
+			proxy.SkipCertVerify = node.SkipCertVerify
 		}
-	}
 
-	if node.Flow != "" {
-		config.WriteString(fmt.Sprintf("    flow: %s\n", node.Flow))
-	}
+	case "ss":
+		proxy.Cipher = node.Cipher
+		proxy.Password = node.Password
+		proxy.Plugin = node.Plugin
+		if node.Plugin != "" {
+			proxy.PluginOpts = node.PluginOpts
+		}
 
-	config.WriteString("    udp: true\n")
-	config.WriteString("\n")
+	case "trojan":
+		proxy.Password = node.Password
+		proxy.SNI = node.SNI
+		proxy.ALPN = node.ALPN
+		proxy.SkipCertVerify = node.SkipCertVerify
+		switch node.Network {
+		case "", "tcp", "ws", "grpc":
+			if err := setMihomoTransport(&proxy, node); err != nil {
+				return proxy, err
+			}
+		default:
+			return proxy, fmt.Errorf("Clash Premium 不支持 Trojan 的 %s 传输", node.Network)
+		}
+
+	default:
+		return proxy, fmt.Errorf("Clash Premium 不支持 %s 节点", node.Type)
+	}
 
-	return config.String()
+	return proxy, nil
 }
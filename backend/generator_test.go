@@ -0,0 +1,231 @@
+// backend/generator_test.go
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+var updateGolden = flag.Bool("update", false, "更新 testdata 中的 golden 文件")
+
+// hostileNames 需要加引号或转义才能正确表示的节点名称
+var hostileNames = []string{
+	`🇭🇰 香港 01`,
+	`say "hi"`,
+	`it's # not a comment`,
+	"line1\nline2",
+	`- looks like a list`,
+	`\U0001F600 literal escape`,
+	`back\slash 🇯🇵 \"mixed\"`,
+	`: colon`,
+	`*anchor`,
+	`true`,
+}
+
+func TestMarshalYAMLRoundTrip(t *testing.T) {
+	for _, name := range hostileNames {
+		t.Run(name, func(t *testing.T) {
+			value := map[string][]string{name: {name}}
+			out, err := marshalYAML(value)
+			if err != nil {
+				t.Fatalf("marshalYAML: %v", err)
+			}
+			var decoded map[string][]string
+			if err := yaml.Unmarshal([]byte(out), &decoded); err != nil {
+				t.Fatalf("Unmarshal: %v\n%s", err, out)
+			}
+			if got := decoded[name]; len(got) != 1 || got[0] != name {
+				t.Errorf("round trip = %q, want %q\n%s", decoded, name, out)
+			}
+			if !strings.Contains(name, `\U`) && strings.Contains(out, `\U`) {
+				t.Errorf("emoji left escaped:\n%s", out)
+			}
+		})
+	}
+}
+
+// hostileNodes 名称、WebSocket 路径与 Host 头都包含特殊字符的节点
+func hostileNodes() []ProxyNode {
+	tlsEnabled := true
+	paths := []string{`/ws?ed=2048#frag`, `/"quoted"`, "/\U0001F680", `/\U0001F600`, "/a: b", `/- x`}
+	hosts := []string{`cdn.example.com`, `"host".example.com`, "host # comment", "🇸🇬.example.com", `\U0001F600.example.com`, "multi\nline"}
+
+	var nodes []ProxyNode
+	for i, name := range hostileNames {
+		node := ProxyNode{
+			Name:    name,
+			Type:    "vmess",
+			Server:  "server.example.com",
+			Port:    443,
+			UUID:    "b831381d-6324-4d53-ad4f-8cda48b30811",
+			Cipher:  "auto",
+			TLS:     &tlsEnabled,
+			Network: "ws",
+			UDP:     true,
+		}
+		node.WSOpts = &struct {
+			Path    string            `yaml:"path"`
+			Headers map[string]string `yaml:"headers,omitempty"`
+		}{
+			Path:    paths[i%len(paths)],
+			Headers: map[string]string{"Host": hosts[i%len(hosts)]},
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// stripGeneratedTime 去掉文件头中的生成时间，使输出可以与 golden 文件比较
+func stripGeneratedTime(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "# 生成时间: ") {
+			lines[i] = "# 生成时间: -"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func assertGolden(t *testing.T, name, content string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("写入 golden 文件失败: %v", err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取 golden 文件失败: %v (使用 -update 生成)", err)
+	}
+	if content != string(want) {
+		t.Errorf("%s 与生成结果不一致 (使用 -update 更新):\n%s", path, content)
+	}
+}
+
+func TestGenerateClashConfigGolden(t *testing.T) {
+	nodes := hostileNodes()
+	tests := []struct {
+		golden  string
+		premium bool
+	}{
+		{"clash_hostile.golden", false},
+		{"clash_premium_hostile.golden", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			content, skipped, err := generateClashConfig(nodes, "hostile\nname", GenerateRequest{}, tt.premium)
+			if err != nil {
+				t.Fatalf("generateClashConfig: %v", err)
+			}
+			if len(skipped) > 0 {
+				t.Fatalf("skipped = %v", skipped)
+			}
+			content = stripGeneratedTime(content)
+			assertGolden(t, tt.golden, content)
+
+			var config ClashConfig
+			if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+				t.Fatalf("生成的配置无法解析: %v", err)
+			}
+			if len(config.Proxies) != len(nodes) {
+				t.Fatalf("got %d proxies, want %d", len(config.Proxies), len(nodes))
+			}
+			for i, proxy := range config.Proxies {
+				node := nodes[i]
+				path, host := proxy.WSPath, proxy.WSHeaders["Host"]
+				if !tt.premium {
+					path, host = proxy.WSOpts.Path, proxy.WSOpts.Headers["Host"]
+				}
+				if proxy.Name != node.Name || path != node.WSOpts.Path || host != node.WSOpts.Headers["Host"] {
+					t.Errorf("proxies[%d] = {%q %q %q}, want {%q %q %q}", i, proxy.Name, path, host,
+						node.Name, node.WSOpts.Path, node.WSOpts.Headers["Host"])
+				}
+			}
+		})
+	}
+}
+
+func TestGRPCMultiModeSkipped(t *testing.T) {
+	tlsEnabled := true
+	grpcNode := func(name, mode string) ProxyNode {
+		node := ProxyNode{
+			Name:    name,
+			Type:    "vmess",
+			Server:  "grpc.example.com",
+			Port:    443,
+			UUID:    "b831381d-6324-4d53-ad4f-8cda48b30811",
+			Cipher:  "auto",
+			TLS:     &tlsEnabled,
+			Network: "grpc",
+		}
+		node.GRPCopts = &struct {
+			ServiceName string `yaml:"service-name,omitempty"`
+			Mode        string `yaml:"mode,omitempty"`
+		}{ServiceName: "svc", Mode: mode}
+		return node
+	}
+	nodes := []ProxyNode{grpcNode("gun", "gun"), grpcNode("multi", "multi")}
+
+	for _, format := range []string{"clash", "clash-premium", "sing-box"} {
+		t.Run(format, func(t *testing.T) {
+			content, _, skipped, err := generateConfigContent(nodes, "grpc", GenerateRequest{Format: format})
+			if err != nil {
+				t.Fatalf("generateConfigContent: %v", err)
+			}
+			if len(skipped) != 1 || !strings.HasPrefix(skipped[0], "multi: ") {
+				t.Errorf("skipped = %v, want the multi mode node", skipped)
+			}
+			if !strings.Contains(content, "svc") {
+				t.Errorf("gun mode node missing from output:\n%s", content)
+			}
+		})
+	}
+}
+
+func TestSingBoxHysteria2PinSkipped(t *testing.T) {
+	tlsEnabled := true
+	nodes := []ProxyNode{
+		{Name: "plain", Type: "hysteria2", Server: "hy2.example.com", Port: 443, Password: "secret", TLS: &tlsEnabled},
+		{Name: "pinned", Type: "hysteria2", Server: "hy2.example.com", Port: 8443, Password: "secret", TLS: &tlsEnabled,
+			CertFingerprint: "4a1bd2f0c3e5"},
+	}
+
+	content, _, skipped, err := generateConfigContent(nodes, "hy2", GenerateRequest{Format: "sing-box"})
+	if err != nil {
+		t.Fatalf("generateConfigContent: %v", err)
+	}
+	if len(skipped) != 1 || !strings.HasPrefix(skipped[0], "pinned: ") {
+		t.Errorf("skipped = %v, want the pinned node", skipped)
+	}
+	if strings.Contains(content, `"pinned"`) || !strings.Contains(content, `"plain"`) {
+		t.Errorf("unexpected outbounds:\n%s", content)
+	}
+}
+
+func TestRuleTemplateWarning(t *testing.T) {
+	tests := []struct {
+		format, template string
+		warn             bool
+	}{
+		{"clash", "full", false},
+		{"clash-premium", "minimal", false},
+		{"sing-box", "default", false},
+		{"surge", "", false},
+		{"sing-box", "full", true},
+		{"loon", "china-direct", true},
+		{"qx", "minimal", true},
+	}
+	for _, tt := range tests {
+		warning := ruleTemplateWarning(GenerateRequest{Format: tt.format, RuleTemplate: tt.template})
+		if (warning != "") != tt.warn {
+			t.Errorf("%s/%s: warning = %q, want warning = %v", tt.format, tt.template, warning, tt.warn)
+		}
+	}
+}
@@ -0,0 +1,608 @@
+// backend/ios_generator.go
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// profileProxyBuilder 将单个节点转换为目标客户端的代理行，无法表示的节点返回错误
+type profileProxyBuilder func(node ProxyNode) (string, error)
+
+// buildProfileProxies 逐个转换节点，返回代理行、可用节点以及被跳过的节点说明
+func buildProfileProxies(nodes []ProxyNode, build profileProxyBuilder) ([]string, []ProxyNode, []string) {
+	var lines, skipped []string
+	var included []ProxyNode
+	for _, node := range nodes {
+		// 这些格式以逗号和等号分隔字段，节点名称中不能包含它们
+		if strings.ContainsAny(node.Name, ",=\r\n") {
+			skipped = append(skipped, fmt.Sprintf("%s: 节点名称包含逗号或等号", node.Name))
+			continue
+		}
+		line, err := build(node)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", node.Name, err))
+			continue
+		}
+		lines = append(lines, line)
+		included = append(included, node)
+	}
+	return lines, included, skipped
+}
+
+// writeProfileHeader 写入配置文件头部注释
+func writeProfileHeader(builder *strings.Builder, target, configName string) {
+	builder.WriteString(fmt.Sprintf("# %s配置文件 - %s\n", target, strings.ReplaceAll(configName, "\n", " ")))
+	builder.WriteString(fmt.Sprintf("# 生成时间: %s\n", time.Now().Format("2006-01-02 15:04:05")))
+	builder.WriteString("# ClashLink 自动生成\n\n")
+}
+
+// splitClashRule 拆分 Clash 规则为类型、值、策略与附加参数 (如 no-resolve)
+func splitClashRule(rule string) (string, string, string, []string, bool) {
+	parts := strings.Split(rule, ",")
+	if len(parts) < 3 {
+		return "", "", "", nil, false
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return strings.ToUpper(parts[0]), parts[1], parts[2], parts[3:], true
+}
+
+// convertRuleToSurge 将 Clash 规则转换为 Surge/Loon 规则，两者语法基本一致
+func convertRuleToSurge(rule string) (string, bool) {
+	ruleType, value, target, options, ok := splitClashRule(rule)
+	if !ok {
+		return "", false
+	}
+
+	switch ruleType {
+	case "DOMAIN", "DOMAIN-SUFFIX", "DOMAIN-KEYWORD", "IP-CIDR", "IP-CIDR6", "GEOIP", "PROCESS-NAME":
+	case "DST-PORT":
+		ruleType = "DEST-PORT"
+	default:
+		return "", false
+	}
+
+	fields := append([]string{ruleType, value, target}, options...)
+	return strings.Join(fields, ","), true
+}
+
+// loonLoadBalanceAlgorithm 将负载均衡策略转换为 Loon 的 algorithm 参数
+func loonLoadBalanceAlgorithm(strategy string) string {
+	if strategy == strategyRoundRobin {
+		return "Round-Robin"
+	}
+	return "PCC"
+}
+
+// surgeLogLevel 将 Clash 日志级别转换为 Surge 日志级别
+func surgeLogLevel(logLevel string) string {
+	switch logLevel {
+	case "debug":
+		return "verbose"
+	case "warning", "error", "silent":
+		return "warning"
+	default:
+		return "notify"
+	}
+}
+
+// GenerateSurgeConfig 生成 Surge 配置文件，返回配置内容与被跳过的节点
+func GenerateSurgeConfig(nodes []ProxyNode, configName string, config GenerateRequest) (string, []string, error) {
+	proxyLines, proxyNodes, skipped := buildProfileProxies(nodes, buildSurgeProxy)
+	if len(proxyNodes) == 0 {
+		return "", skipped, fmt.Errorf("没有 Surge 支持的节点")
+	}
+
+	mixedPort := config.MixedPort
+	if mixedPort == 0 {
+		mixedPort = 7890
+	}
+
+	var builder strings.Builder
+	writeProfileHeader(&builder, "Surge", configName)
+
+	builder.WriteString("[General]\n")
+	builder.WriteString(fmt.Sprintf("loglevel = %s\n", surgeLogLevel(config.LogLevel)))
+	builder.WriteString(fmt.Sprintf("ipv6 = %t\n", config.EnableIPv6))
+	builder.WriteString("dns-server = 223.5.5.5, 114.114.114.114, system\n")
+	builder.WriteString("skip-proxy = 127.0.0.1, 192.168.0.0/16, 10.0.0.0/8, 172.16.0.0/12, 100.64.0.0/10, localhost, *.local\n")
+	builder.WriteString(fmt.Sprintf("internet-test-url = %s\n", proxyTestURL))
+	builder.WriteString(fmt.Sprintf("proxy-test-url = %s\n", proxyTestURL))
+	builder.WriteString(fmt.Sprintf("allow-wifi-access = %t\n", config.AllowLan))
+	builder.WriteString(fmt.Sprintf("wifi-access-http-port = %d\n", mixedPort))
+	builder.WriteString(fmt.Sprintf("wifi-access-socks5-port = %d\n", mixedPort+1))
+
+	builder.WriteString("\n[Proxy]\n")
+	for _, line := range proxyLines {
+		builder.WriteString(line + "\n")
+	}
+
+	proxyGroups, err := buildProxyGroups(proxyNodes, config)
+	if err != nil {
+		return "", skipped, err
+	}
+	builder.WriteString("\n[Proxy Group]\n")
+	for _, group := range proxyGroups {
+		line := fmt.Sprintf("%s = %s, %s", group.Name, group.Type, strings.Join(group.Proxies, ", "))
+		if group.Type != groupTypeSelect {
+			line += fmt.Sprintf(", url=%s, interval=%d", group.URL, group.Interval)
+		}
+		switch group.Type {
+		case groupTypeURLTest:
+			line += fmt.Sprintf(", tolerance=%d", group.Tolerance)
+		case groupTypeLoadBalance:
+			// Surge 的负载均衡只能选择是否保持同一目标使用同一节点
+			if group.Strategy != strategyRoundRobin {
+				line += ", persistent=true"
+			}
+		}
+		builder.WriteString(line + "\n")
+	}
+
+	builder.WriteString("\n[Rule]\n")
+	for _, rule := range append(defaultRules(), splitCustomRules(config.CustomRules)...) {
+		if surgeRule, ok := convertRuleToSurge(rule); ok {
+			builder.WriteString(surgeRule + "\n")
+		}
+	}
+	builder.WriteString(fmt.Sprintf("FINAL,%s,dns-failed\n", groupNodeSelect))
+
+	return builder.String(), skipped, nil
+}
+
+// buildSurgeProxy 将 ProxyNode 转换为 Surge [Proxy] 中的一行
+// 密码加引号输出，避免其中的逗号或等号破坏参数的划分
+func buildSurgeProxy(node ProxyNode) (string, error) {
+	var fields []string
+	tlsEnabled := node.TLS != nil && *node.TLS
+
+	switch node.Type {
+	case "ss":
+		fields = append(fields, "ss", node.Server, fmt.Sprint(node.Port),
+			"encrypt-method="+node.Cipher, fmt.Sprintf("password=%q", node.Password))
+		switch node.Plugin {
+		case "":
+		case "obfs":
+			fields = append(fields, fmt.Sprintf("obfs=%v", node.PluginOpts["mode"]))
+			if host, ok := node.PluginOpts["host"]; ok {
+				fields = append(fields, fmt.Sprintf("obfs-host=%v", host))
+			}
+		default:
+			return "", fmt.Errorf("Surge 不支持 %s 插件", node.Plugin)
+		}
+		fields = append(fields, "udp-relay=true")
+
+	case "vmess", "trojan":
+		if node.Type == "vmess" {
+			fields = append(fields, "vmess", node.Server, fmt.Sprint(node.Port), "username="+node.UUID)
+			if node.AlterID == 0 {
+				fields = append(fields, "vmess-aead=true")
+			}
+			if tlsEnabled {
+				fields = append(fields, "tls=true")
+			}
+		} else {
+			fields = append(fields, "trojan", node.Server, fmt.Sprint(node.Port), fmt.Sprintf("password=%q", node.Password))
+		}
+
+		switch node.Network {
+		case "", "tcp":
+		case "ws":
+			fields = append(fields, "ws=true")
+			if node.WSOpts != nil {
+				if node.WSOpts.Path != "" {
+					fields = append(fields, "ws-path="+node.WSOpts.Path)
+				}
+				if host := node.WSOpts.Headers["Host"]; host != "" {
+					fields = append(fields, fmt.Sprintf("ws-headers=Host:%q", host))
+				}
+			}
+		default:
+			return "", fmt.Errorf("Surge 不支持 %s 传输", node.Network)
+		}
+
+	case "hysteria2":
+		if node.Obfs != "" {
+			return "", fmt.Errorf("Surge 不支持 Hysteria2 混淆")
+		}
+		fields = append(fields, "hysteria2", node.Server, fmt.Sprint(node.Port), fmt.Sprintf("password=%q", node.Password))
+		if node.Ports != "" {
+			fields = append(fields, fmt.Sprintf("port-hopping=%q", strings.ReplaceAll(node.Ports, ",", ";")))
+		}
+
+	case "tuic":
+		fields = append(fields, "tuic-v5", node.Server, fmt.Sprint(node.Port),
+			fmt.Sprintf("password=%q", node.Password), "uuid="+node.UUID)
+		if len(node.ALPN) > 0 {
+			fields = append(fields, "alpn="+node.ALPN[0])
+		}
+
+	default:
+		return "", fmt.Errorf("Surge 不支持 %s 节点", node.Type)
+	}
+
+	// TLS 相关参数
+	if tlsEnabled {
+		if node.SNI != "" {
+			fields = append(fields, "sni="+node.SNI)
+		}
+		if node.SkipCertVerify {
+			fields = append(fields, "skip-cert-verify=true")
+		}
+		if node.CertFingerprint != "" {
+			fields = append(fields, "server-cert-fingerprint-sha256="+node.CertFingerprint)
+		}
+	}
+
+	return node.Name + " = " + strings.Join(fields, ", "), nil
+}
+
+// GenerateLoonConfig 生成 Loon 配置文件，返回配置内容与被跳过的节点
+func GenerateLoonConfig(nodes []ProxyNode, configName string, config GenerateRequest) (string, []string, error) {
+	proxyLines, proxyNodes, skipped := buildProfileProxies(nodes, buildLoonProxy)
+	if len(proxyNodes) == 0 {
+		return "", skipped, fmt.Errorf("没有 Loon 支持的节点")
+	}
+
+	mixedPort := config.MixedPort
+	if mixedPort == 0 {
+		mixedPort = 7890
+	}
+
+	var builder strings.Builder
+	writeProfileHeader(&builder, "Loon", configName)
+
+	builder.WriteString("[General]\n")
+	builder.WriteString(fmt.Sprintf("ipv6 = %t\n", config.EnableIPv6))
+	builder.WriteString("dns-server = system, 223.5.5.5, 114.114.114.114\n")
+	builder.WriteString("skip-proxy = 127.0.0.1, 192.168.0.0/16, 10.0.0.0/8, 172.16.0.0/12, 100.64.0.0/10, localhost, *.local\n")
+	builder.WriteString(fmt.Sprintf("proxy-test-url = %s\n", proxyTestURL))
+	builder.WriteString(fmt.Sprintf("allow-wifi-access = %t\n", config.AllowLan))
+	builder.WriteString(fmt.Sprintf("wifi-access-http-port = %d\n", mixedPort))
+	builder.WriteString(fmt.Sprintf("wifi-access-socks5-port = %d\n", mixedPort+1))
+
+	builder.WriteString("\n[Proxy]\n")
+	for _, line := range proxyLines {
+		builder.WriteString(line + "\n")
+	}
+
+	proxyGroups, err := buildProxyGroups(proxyNodes, config)
+	if err != nil {
+		return "", skipped, err
+	}
+	builder.WriteString("\n[Proxy Group]\n")
+	for _, group := range proxyGroups {
+		line := fmt.Sprintf("%s = %s,%s", group.Name, group.Type, strings.Join(group.Proxies, ","))
+		if group.Type != groupTypeSelect {
+			line += fmt.Sprintf(",url = %s,interval = %d", group.URL, group.Interval)
+		}
+		switch group.Type {
+		case groupTypeURLTest:
+			line += fmt.Sprintf(",tolerance = %d", group.Tolerance)
+		case groupTypeLoadBalance:
+			line += ",algorithm = " + loonLoadBalanceAlgorithm(group.Strategy)
+		}
+		builder.WriteString(line + "\n")
+	}
+
+	builder.WriteString("\n[Rule]\n")
+	for _, rule := range append(defaultRules(), splitCustomRules(config.CustomRules)...) {
+		if loonRule, ok := convertRuleToSurge(rule); ok {
+			builder.WriteString(loonRule + "\n")
+		}
+	}
+	builder.WriteString(fmt.Sprintf("FINAL,%s\n", groupNodeSelect))
+
+	return builder.String(), skipped, nil
+}
+
+// buildLoonProxy 将 ProxyNode 转换为 Loon [Proxy] 中的一行
+func buildLoonProxy(node ProxyNode) (string, error) {
+	var fields []string
+	tlsEnabled := node.TLS != nil && *node.TLS
+
+	switch node.Type {
+	case "ss":
+		fields = append(fields, "Shadowsocks", node.Server, fmt.Sprint(node.Port), node.Cipher, fmt.Sprintf("%q", node.Password))
+		switch node.Plugin {
+		case "":
+		case "obfs":
+			fields = append(fields, fmt.Sprintf("obfs-name=%v", node.PluginOpts["mode"]))
+			if host, ok := node.PluginOpts["host"]; ok {
+				fields = append(fields, fmt.Sprintf("obfs-host=%v", host))
+			}
+		default:
+			return "", fmt.Errorf("Loon 不支持 %s 插件", node.Plugin)
+		}
+		fields = append(fields, "udp=true")
+		return node.Name + " = " + strings.Join(fields, ","), nil
+
+	case "vmess":
+		cipher := node.Cipher
+		if cipher == "" {
+			cipher = "auto"
+		}
+		fields = append(fields, "vmess", node.Server, fmt.Sprint(node.Port), cipher, fmt.Sprintf("%q", node.UUID))
+		fields = append(fields, fmt.Sprintf("alterId=%d", node.AlterID))
+
+	case "vless":
+		fields = append(fields, "VLESS", node.Server, fmt.Sprint(node.Port), fmt.Sprintf("%q", node.UUID))
+		if node.Flow != "" {
+			fields = append(fields, "flow="+node.Flow)
+		}
+		if node.RealityOpts != nil {
+			fields = append(fields, fmt.Sprintf("public-key=%q", node.RealityOpts.PublicKey))
+			if node.RealityOpts.ShortID != "" {
+				fields = append(fields, "short-id="+node.RealityOpts.ShortID)
+			}
+		}
+
+	case "trojan":
+		fields = append(fields, "trojan", node.Server, fmt.Sprint(node.Port), fmt.Sprintf("%q", node.Password))
+
+	case "hysteria2":
+		fields = append(fields, "Hysteria2", node.Server, fmt.Sprint(node.Port), fmt.Sprintf("%q", node.Password))
+		if node.Obfs == "salamander" {
+			fields = append(fields, "salamander-password="+node.ObfsPassword)
+		} else if node.Obfs != "" {
+			return "", fmt.Errorf("Loon 不支持 %s 混淆", node.Obfs)
+		}
+		fields = append(fields, "udp=true")
+
+	default:
+		return "", fmt.Errorf("Loon 不支持 %s 节点", node.Type)
+	}
+
+	// vmess/vless/trojan 的传输层参数
+	if node.Type != "hysteria2" {
+		switch node.Network {
+		case "", "tcp":
+			fields = append(fields, "transport=tcp")
+		case "ws":
+			fields = append(fields, "transport=ws")
+			if node.WSOpts != nil {
+				if node.WSOpts.Path != "" {
+					fields = append(fields, "path="+node.WSOpts.Path)
+				}
+				if host := node.WSOpts.Headers["Host"]; host != "" {
+					fields = append(fields, "host="+host)
+				}
+			}
+		case "h2", "http":
+			if node.Type == "trojan" {
+				return "", fmt.Errorf("Loon 不支持 Trojan 的 %s 传输", node.Network)
+			}
+			fields = append(fields, "transport=http")
+			if node.HTTPOpts != nil {
+				if node.HTTPOpts.Path != "" {
+					fields = append(fields, "path="+node.HTTPOpts.Path)
+				}
+				if host := node.HTTPOpts.Headers["Host"]; host != "" {
+					fields = append(fields, "host="+host)
+				}
+			}
+		default:
+			return "", fmt.Errorf("Loon 不支持 %s 传输", node.Network)
+		}
+		if node.Type != "trojan" {
+			fields = append(fields, fmt.Sprintf("over-tls=%t", tlsEnabled))
+		}
+	}
+
+	// TLS 相关参数
+	if tlsEnabled {
+		if node.SNI != "" {
+			fields = append(fields, "sni="+node.SNI)
+		}
+		if len(node.ALPN) > 0 && node.Type != "vless" {
+			fields = append(fields, "alpn="+strings.Join(node.ALPN, ":"))
+		}
+		fields = append(fields, fmt.Sprintf("skip-cert-verify=%t", node.SkipCertVerify))
+	}
+
+	return node.Name + " = " + strings.Join(fields, ","), nil
+}
+
+// GenerateQuantumultXConfig 生成 Quantumult X 配置文件，返回配置内容与被跳过的节点
+func GenerateQuantumultXConfig(nodes []ProxyNode, configName string, config GenerateRequest) (string, []string, error) {
+	serverLines, proxyNodes, skipped := buildProfileProxies(nodes, buildQuantumultXServer)
+	if len(proxyNodes) == 0 {
+		return "", skipped, fmt.Errorf("没有 Quantumult X 支持的节点")
+	}
+
+	var builder strings.Builder
+	writeProfileHeader(&builder, "Quantumult X", configName)
+
+	builder.WriteString("[general]\n")
+	builder.WriteString(fmt.Sprintf("server_check_url = %s\n", proxyTestURL))
+	builder.WriteString("excluded_routes = 192.168.0.0/16, 172.16.0.0/12, 10.0.0.0/8, 100.64.0.0/10, 127.0.0.0/8\n")
+
+	builder.WriteString("\n[dns]\n")
+	if !config.EnableIPv6 {
+		builder.WriteString("no-ipv6\n")
+	}
+	builder.WriteString("server = 223.5.5.5\n")
+	builder.WriteString("server = 114.114.114.114\n")
+
+	proxyGroups, err := buildProxyGroups(proxyNodes, config)
+	if err != nil {
+		return "", skipped, err
+	}
+	builder.WriteString("\n[policy]\n")
+	for _, group := range proxyGroups {
+		policies := make([]string, len(group.Proxies))
+		for i, proxy := range group.Proxies {
+			if proxy == "DIRECT" {
+				proxy = "direct"
+			}
+			policies[i] = proxy
+		}
+		// Quantumult X 统一使用 server_check_url 测速，负载均衡只支持轮询
+		switch group.Type {
+		case groupTypeURLTest:
+			builder.WriteString(fmt.Sprintf("url-latency-benchmark = %s, %s, check-interval=%d, tolerance=%d\n",
+				group.Name, strings.Join(policies, ", "), group.Interval, group.Tolerance))
+		case groupTypeFallback:
+			builder.WriteString(fmt.Sprintf("available = %s, %s, check-interval=%d\n",
+				group.Name, strings.Join(policies, ", "), group.Interval))
+		case groupTypeLoadBalance:
+			builder.WriteString(fmt.Sprintf("round-robin = %s, %s\n", group.Name, strings.Join(policies, ", ")))
+		default:
+			builder.WriteString(fmt.Sprintf("static = %s, %s\n", group.Name, strings.Join(policies, ", ")))
+		}
+	}
+
+	builder.WriteString("\n[server_local]\n")
+	for _, line := range serverLines {
+		builder.WriteString(line + "\n")
+	}
+
+	builder.WriteString("\n[filter_local]\n")
+	for _, rule := range append(defaultRules(), splitCustomRules(config.CustomRules)...) {
+		if filter, ok := convertRuleToQuantumultX(rule); ok {
+			builder.WriteString(filter + "\n")
+		}
+	}
+	builder.WriteString(fmt.Sprintf("final, %s\n", groupNodeSelect))
+
+	return builder.String(), skipped, nil
+}
+
+// buildQuantumultXServer 将 ProxyNode 转换为 Quantumult X [server_local] 中的一行
+// 密码加引号输出，避免其中的逗号或等号破坏参数的划分
+func buildQuantumultXServer(node ProxyNode) (string, error) {
+	address := fmt.Sprintf("%s:%d", node.Server, node.Port)
+	if strings.Contains(node.Server, ":") {
+		address = fmt.Sprintf("[%s]:%d", node.Server, node.Port)
+	}
+	tlsEnabled := node.TLS != nil && *node.TLS
+
+	var fields []string
+	switch node.Type {
+	case "ss":
+		fields = append(fields, "shadowsocks="+address, "method="+node.Cipher, fmt.Sprintf("password=%q", node.Password))
+		switch node.Plugin {
+		case "":
+		case "obfs":
+			fields = append(fields, fmt.Sprintf("obfs=%v", node.PluginOpts["mode"]))
+			if host, ok := node.PluginOpts["host"]; ok {
+				fields = append(fields, fmt.Sprintf("obfs-host=%v", host))
+			}
+		case "v2ray-plugin":
+			obfs := "ws"
+			if enabled, _ := node.PluginOpts["tls"].(bool); enabled {
+				obfs = "wss"
+			}
+			fields = append(fields, "obfs="+obfs)
+			if host, ok := node.PluginOpts["host"]; ok {
+				fields = append(fields, fmt.Sprintf("obfs-host=%v", host))
+			}
+			if path, ok := node.PluginOpts["path"]; ok {
+				fields = append(fields, fmt.Sprintf("obfs-uri=%v", path))
+			}
+		default:
+			return "", fmt.Errorf("Quantumult X 不支持 %s 插件", node.Plugin)
+		}
+		fields = append(fields, "udp-relay=true", "tag="+node.Name)
+		return strings.Join(fields, ", "), nil
+
+	case "vmess":
+		// Quantumult X 不支持 auto，使用 chacha20-ietf-poly1305 代替
+		method := node.Cipher
+		switch method {
+		case "", "auto":
+			method = "chacha20-ietf-poly1305"
+		case "zero":
+			method = "none"
+		}
+		fields = append(fields, "vmess="+address, "method="+method, "password="+node.UUID)
+		if node.AlterID == 0 {
+			fields = append(fields, "aead=true")
+		}
+
+	case "vless":
+		fields = append(fields, "vless="+address, "method=none", "password="+node.UUID)
+		if node.Flow != "" {
+			fields = append(fields, "vless-flow="+node.Flow)
+		}
+		if node.RealityOpts != nil {
+			fields = append(fields, "reality-base64-pubkey="+node.RealityOpts.PublicKey)
+			if node.RealityOpts.ShortID != "" {
+				fields = append(fields, "reality-hex-shortid="+node.RealityOpts.ShortID)
+			}
+		}
+
+	case "trojan":
+		fields = append(fields, "trojan="+address, fmt.Sprintf("password=%q", node.Password))
+
+	default:
+		return "", fmt.Errorf("Quantumult X 不支持 %s 节点", node.Type)
+	}
+
+	// 传输层与 TLS 参数
+	switch node.Network {
+	case "", "tcp":
+		if tlsEnabled {
+			fields = append(fields, "obfs=over-tls")
+		}
+	case "ws":
+		obfs := "ws"
+		if tlsEnabled {
+			obfs = "wss"
+		}
+		fields = append(fields, "obfs="+obfs)
+		if node.WSOpts != nil {
+			if host := node.WSOpts.Headers["Host"]; host != "" {
+				fields = append(fields, "obfs-host="+host)
+			}
+			if node.WSOpts.Path != "" {
+				fields = append(fields, "obfs-uri="+node.WSOpts.Path)
+			}
+		}
+	default:
+		return "", fmt.Errorf("Quantumult X 不支持 %s 传输", node.Network)
+	}
+
+	if tlsEnabled {
+		if node.SNI != "" {
+			fields = append(fields, "tls-host="+node.SNI)
+		}
+		fields = append(fields, fmt.Sprintf("tls-verification=%t", !node.SkipCertVerify))
+	}
+
+	fields = append(fields, "tag="+node.Name)
+	return strings.Join(fields, ", "), nil
+}
+
+// convertRuleToQuantumultX 将 Clash 规则转换为 Quantumult X 的 filter_local 规则
+func convertRuleToQuantumultX(rule string) (string, bool) {
+	ruleType, value, target, _, ok := splitClashRule(rule)
+	if !ok {
+		return "", false
+	}
+
+	filterTypes := map[string]string{
+		"DOMAIN":         "host",
+		"DOMAIN-SUFFIX":  "host-suffix",
+		"DOMAIN-KEYWORD": "host-keyword",
+		"IP-CIDR":        "ip-cidr",
+		"IP-CIDR6":       "ip6-cidr",
+		"GEOIP":          "geoip",
+	}
+	filterType, supported := filterTypes[ruleType]
+	if !supported {
+		return "", false
+	}
+
+	switch strings.ToUpper(target) {
+	case "DIRECT":
+		target = "direct"
+	case "REJECT", "REJECT-DROP":
+		target = "reject"
+	}
+
+	return fmt.Sprintf("%s, %s, %s", filterType, value, target), true
+}
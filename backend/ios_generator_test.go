@@ -0,0 +1,68 @@
+// backend/ios_generator_test.go
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// splitProfileFields 按逗号拆分配置行，双引号内的逗号不作为分隔符
+func splitProfileFields(line string) []string {
+	var fields []string
+	var current strings.Builder
+	quoted, escaped := false, false
+	for _, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quoted:
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			fields = append(fields, strings.TrimSpace(current.String()))
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	return append(fields, strings.TrimSpace(current.String()))
+}
+
+func TestProfilePasswordQuoted(t *testing.T) {
+	const password = `p,a=s"s`
+	nodes := []ProxyNode{
+		{Name: "ss", Type: "ss", Server: "ss.example.com", Port: 8388, Cipher: "aes-128-gcm", Password: password},
+		{Name: "trojan", Type: "trojan", Server: "trojan.example.com", Port: 443, Password: password},
+	}
+	builders := map[string]func(ProxyNode) (string, error){
+		"surge":       buildSurgeProxy,
+		"quantumultx": buildQuantumultXServer,
+	}
+
+	for format, build := range builders {
+		for _, node := range nodes {
+			t.Run(format+"/"+node.Name, func(t *testing.T) {
+				line, err := build(node)
+				if err != nil {
+					t.Fatalf("build: %v", err)
+				}
+				var found bool
+				for _, field := range splitProfileFields(line) {
+					value, ok := strings.CutPrefix(field, "password=")
+					if !ok {
+						continue
+					}
+					found = true
+					if got, err := strconv.Unquote(value); err != nil || got != password {
+						t.Errorf("password = %s, want %q", value, password)
+					}
+				}
+				if !found {
+					t.Errorf("password field missing: %s", line)
+				}
+			})
+		}
+	}
+}
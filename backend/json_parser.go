@@ -0,0 +1,568 @@
+// backend/json_parser.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SingBoxOutbound sing-box outbounds 中的单个出站，同时用于解析与生成 sing-box 配置
+type SingBoxOutbound struct {
+	Type              string            `json:"type"`
+	Tag               string            `json:"tag"`
+	Server            string            `json:"server,omitempty"`
+	ServerPort        int               `json:"server_port,omitempty"`
+	ServerPorts       []string          `json:"server_ports,omitempty"` // hysteria2 端口跳跃，如 "2080:3000"
+	UUID              string            `json:"uuid,omitempty"`
+	Password          string            `json:"password,omitempty"`
+	AlterID           int               `json:"alter_id,omitempty"`
+	Security          string            `json:"security,omitempty"` // vmess 加密方式
+	Method            string            `json:"method,omitempty"`   // shadowsocks 加密方式
+	Plugin            string            `json:"plugin,omitempty"`
+	PluginOpts        string            `json:"plugin_opts,omitempty"`
+	Flow              string            `json:"flow,omitempty"`
+	CongestionControl string            `json:"congestion_control,omitempty"`
+	UDPRelayMode      string            `json:"udp_relay_mode,omitempty"`
+	Obfs              *SingBoxObfs      `json:"obfs,omitempty"`
+	TLS               *SingBoxTLS       `json:"tls,omitempty"`
+	Transport         *SingBoxTransport `json:"transport,omitempty"`
+	// selector/urltest 代理组字段
+	Outbounds []string `json:"outbounds,omitempty"`
+	Default   string   `json:"default,omitempty"`
+	URL       string   `json:"url,omitempty"`
+	Interval  string   `json:"interval,omitempty"`
+	Tolerance int      `json:"tolerance,omitempty"`
+}
+
+// SingBoxObfs sing-box hysteria2 混淆配置
+type SingBoxObfs struct {
+	Type     string `json:"type"`
+	Password string `json:"password,omitempty"`
+}
+
+// SingBoxTLS sing-box 出站 TLS 配置
+type SingBoxTLS struct {
+	Enabled    bool            `json:"enabled"`
+	ServerName string          `json:"server_name,omitempty"`
+	Insecure   bool            `json:"insecure,omitempty"`
+	ALPN       []string        `json:"alpn,omitempty"`
+	UTLS       *SingBoxUTLS    `json:"utls,omitempty"`
+	Reality    *SingBoxReality `json:"reality,omitempty"`
+}
+
+// SingBoxUTLS sing-box uTLS 客户端指纹配置
+type SingBoxUTLS struct {
+	Enabled     bool   `json:"enabled"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// SingBoxReality sing-box Reality 配置
+type SingBoxReality struct {
+	Enabled   bool   `json:"enabled"`
+	PublicKey string `json:"public_key"`
+	ShortID   string `json:"short_id,omitempty"`
+}
+
+// SingBoxTransport sing-box V2Ray 传输层配置
+type SingBoxTransport struct {
+	Type        string            `json:"type"` // ws, grpc, http
+	Path        string            `json:"path,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Host        interface{}       `json:"host,omitempty"` // http 传输中为字符串列表
+	Method      string            `json:"method,omitempty"`
+	ServiceName string            `json:"service_name,omitempty"`
+}
+
+// XrayOutbound Xray/V2Ray outbounds 中的单个出站
+type XrayOutbound struct {
+	Protocol string `json:"protocol"`
+	Tag      string `json:"tag"`
+	Settings struct {
+		Vnext []struct {
+			Address string `json:"address"`
+			Port    int    `json:"port"`
+			Users   []struct {
+				ID       string `json:"id"`
+				AlterID  int    `json:"alterId"`
+				Security string `json:"security"`
+				Flow     string `json:"flow"`
+			} `json:"users"`
+		} `json:"vnext"`
+		Servers []struct {
+			Address  string `json:"address"`
+			Port     int    `json:"port"`
+			Password string `json:"password"`
+			Method   string `json:"method"`
+		} `json:"servers"`
+	} `json:"settings"`
+	StreamSettings *struct {
+		Network     string `json:"network"`
+		Security    string `json:"security"`
+		TLSSettings *struct {
+			ServerName    string   `json:"serverName"`
+			AllowInsecure bool     `json:"allowInsecure"`
+			ALPN          []string `json:"alpn"`
+			Fingerprint   string   `json:"fingerprint"`
+		} `json:"tlsSettings"`
+		RealitySettings *struct {
+			ServerName  string `json:"serverName"`
+			PublicKey   string `json:"publicKey"`
+			ShortID     string `json:"shortId"`
+			SpiderX     string `json:"spiderX"`
+			Fingerprint string `json:"fingerprint"`
+		} `json:"realitySettings"`
+		WSSettings *struct {
+			Path    string            `json:"path"`
+			Host    string            `json:"host"`
+			Headers map[string]string `json:"headers"`
+		} `json:"wsSettings"`
+		GRPCSettings *struct {
+			ServiceName string `json:"serviceName"`
+			MultiMode   bool   `json:"multiMode"`
+		} `json:"grpcSettings"`
+		HTTPSettings *struct {
+			Host []string `json:"host"`
+			Path string   `json:"path"`
+		} `json:"httpSettings"`
+	} `json:"streamSettings"`
+}
+
+// nonProxyOutbounds 不代表代理节点的出站类型，解析时静默跳过
+var nonProxyOutbounds = map[string]bool{
+	"direct":    true,
+	"block":     true,
+	"dns":       true,
+	"selector":  true,
+	"urltest":   true,
+	"freedom":   true,
+	"blackhole": true,
+	"loopback":  true,
+}
+
+// isJSONConfig 判断输入是否为 JSON 格式的 sing-box/Xray 配置
+func isJSONConfig(content string) bool {
+	trimmed := strings.TrimSpace(content)
+	return strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")
+}
+
+// ParseJSONConfig 读取 sing-box 或 Xray/V2Ray 配置中的 outbounds 并转换为 ProxyNode 列表
+// 输入可以是完整配置、outbounds 数组或单个出站对象，每个出站会自动识别所属格式
+func ParseJSONConfig(content string) ([]ProxyNode, error) {
+	content = strings.TrimSpace(content)
+
+	var outbounds []json.RawMessage
+	if strings.HasPrefix(content, "[") {
+		if err := json.Unmarshal([]byte(content), &outbounds); err != nil {
+			return nil, fmt.Errorf("JSON配置解析失败: %w", err)
+		}
+	} else {
+		var config struct {
+			Outbounds []json.RawMessage `json:"outbounds"`
+		}
+		if err := json.Unmarshal([]byte(content), &config); err != nil {
+			return nil, fmt.Errorf("JSON配置解析失败: %w", err)
+		}
+		outbounds = config.Outbounds
+		// 没有 outbounds 字段时视为单个出站
+		if outbounds == nil {
+			outbounds = []json.RawMessage{json.RawMessage(content)}
+		}
+	}
+
+	var parsedNodes []ProxyNode
+	var errors []string
+
+	for i, raw := range outbounds {
+		var probe struct {
+			Type     string `json:"type"`
+			Protocol string `json:"protocol"`
+		}
+		if err := json.Unmarshal(raw, &probe); err != nil {
+			errors = append(errors, fmt.Sprintf("第%d个出站解析失败: %v", i+1, err))
+			continue
+		}
+
+		var node *ProxyNode
+		var err error
+
+		switch {
+		case probe.Protocol != "":
+			if nonProxyOutbounds[probe.Protocol] {
+				continue
+			}
+			var outbound XrayOutbound
+			if err = json.Unmarshal(raw, &outbound); err == nil {
+				node, err = parseXrayOutbound(outbound)
+			}
+		case probe.Type != "":
+			if nonProxyOutbounds[probe.Type] {
+				continue
+			}
+			var outbound SingBoxOutbound
+			if err = json.Unmarshal(raw, &outbound); err == nil {
+				node, err = parseSingBoxOutbound(outbound)
+			}
+		default:
+			err = fmt.Errorf("无法识别的出站格式")
+		}
+
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("第%d个出站解析失败: %v", i+1, err))
+			continue
+		}
+		parsedNodes = append(parsedNodes, *node)
+	}
+
+	if len(parsedNodes) == 0 {
+		if len(errors) > 0 {
+			return nil, fmt.Errorf("解析失败: %s", strings.Join(errors, "; "))
+		}
+		return nil, fmt.Errorf("JSON配置中没有代理出站")
+	}
+
+	return parsedNodes, nil
+}
+
+// parseSingBoxOutbound 将 sing-box 出站转换为 ProxyNode
+func parseSingBoxOutbound(outbound SingBoxOutbound) (*ProxyNode, error) {
+	if outbound.Server == "" || outbound.ServerPort <= 0 {
+		return nil, fmt.Errorf("缺少服务器地址或端口")
+	}
+
+	node := &ProxyNode{
+		Name:   outbound.Tag,
+		Server: outbound.Server,
+		Port:   outbound.ServerPort,
+		UDP:    true,
+	}
+
+	switch outbound.Type {
+	case "vmess":
+		node.Type = "vmess"
+		node.UUID = outbound.UUID
+		node.AlterID = outbound.AlterID
+		node.Cipher = outbound.Security
+		if node.Cipher == "" {
+			node.Cipher = "auto"
+		}
+	case "vless":
+		node.Type = "vless"
+		node.UUID = outbound.UUID
+		node.Password = outbound.UUID
+		node.Flow = outbound.Flow
+	case "trojan":
+		node.Type = "trojan"
+		node.Password = outbound.Password
+	case "shadowsocks":
+		node.Type = "ss"
+		node.Cipher = outbound.Method
+		node.Password = outbound.Password
+		if outbound.Plugin != "" {
+			node.Plugin, node.PluginOpts = parseShadowsocksPlugin(outbound.Plugin + ";" + outbound.PluginOpts)
+		}
+	case "hysteria2":
+		node.Type = "hysteria2"
+		node.Password = outbound.Password
+		if len(outbound.ServerPorts) > 0 {
+			node.Ports = strings.ReplaceAll(strings.Join(outbound.ServerPorts, ","), ":", "-")
+		}
+		if outbound.Obfs != nil && outbound.Obfs.Type != "" {
+			node.Obfs = outbound.Obfs.Type
+			node.ObfsPassword = outbound.Obfs.Password
+		}
+	case "tuic":
+		node.Type = "tuic"
+		node.UUID = outbound.UUID
+		node.Password = outbound.Password
+		node.CongestionControl = outbound.CongestionControl
+		node.UDPRelayMode = outbound.UDPRelayMode
+	default:
+		return nil, fmt.Errorf("不支持的出站类型: %s", outbound.Type)
+	}
+
+	if (node.Type == "vmess" || node.Type == "vless" || node.Type == "tuic") && node.UUID == "" {
+		return nil, fmt.Errorf("缺少UUID")
+	}
+	if (node.Type == "trojan" || node.Type == "ss") && node.Password == "" {
+		return nil, fmt.Errorf("缺少密码")
+	}
+
+	// TLS 配置，trojan/hysteria2/tuic 始终启用
+	tlsEnabled := node.Type == "trojan" || node.Type == "hysteria2" || node.Type == "tuic"
+	if tls := outbound.TLS; tls != nil {
+		tlsEnabled = tlsEnabled || tls.Enabled
+		node.SNI = tls.ServerName
+		node.SkipCertVerify = tls.Insecure
+		node.ALPN = tls.ALPN
+		if tls.UTLS != nil && tls.UTLS.Enabled {
+			node.Fingerprint = tls.UTLS.Fingerprint
+		}
+		if tls.Reality != nil && tls.Reality.Enabled {
+			if tls.Reality.PublicKey == "" {
+				return nil, fmt.Errorf("Reality配置缺少公钥(public_key)")
+			}
+			node.RealityOpts = &struct {
+				PublicKey string `yaml:"public-key"`
+				ShortID   string `yaml:"short-id,omitempty"`
+				SpiderX   string `yaml:"-"`
+			}{
+				PublicKey: tls.Reality.PublicKey,
+				ShortID:   tls.Reality.ShortID,
+			}
+			if node.Fingerprint == "" {
+				node.Fingerprint = "chrome"
+			}
+		}
+	}
+	if node.Type != "ss" {
+		node.TLS = &tlsEnabled
+	}
+
+	// 传输层配置
+	node.Network = "tcp"
+	if transport := outbound.Transport; transport != nil {
+		switch transport.Type {
+		case "ws":
+			node.Network = "ws"
+			node.WSOpts = &struct {
+				Path    string            `yaml:"path"`
+				Headers map[string]string `yaml:"headers,omitempty"`
+			}{
+				Path:    transport.Path,
+				Headers: transport.Headers,
+			}
+		case "grpc":
+			node.Network = "grpc"
+			node.GRPCopts = &struct {
+				ServiceName string `yaml:"service-name,omitempty"`
+				Mode        string `yaml:"mode,omitempty"`
+			}{
+				ServiceName: transport.ServiceName,
+				Mode:        "gun",
+			}
+		case "http":
+			// sing-box 的 http 传输在启用 TLS 时为 HTTP/2，否则为明文 HTTP/1.1
+			node.Network = "http"
+			if tlsEnabled {
+				node.Network = "h2"
+			}
+			host := node.Server
+			switch h := transport.Host.(type) {
+			case string:
+				host = h
+			case []interface{}:
+				if len(h) > 0 {
+					host = interfaceToString(h[0])
+				}
+			}
+			method := transport.Method
+			if method == "" {
+				method = "GET"
+			}
+			node.HTTPOpts = &struct {
+				Method  string            `yaml:"method,omitempty"`
+				Headers map[string]string `yaml:"headers,omitempty"`
+				Path    string            `yaml:"path,omitempty"`
+			}{
+				Method: method,
+				Path:   transport.Path,
+				Headers: map[string]string{
+					"Host": host,
+				},
+			}
+		case "":
+		default:
+			return nil, fmt.Errorf("不支持的传输类型: %s", transport.Type)
+		}
+	}
+
+	if node.Type == "ss" || node.Type == "hysteria2" || node.Type == "tuic" {
+		node.Network = ""
+	}
+
+	if node.Name == "" {
+		node.Name = fmt.Sprintf("%s-%s:%d", node.Type, node.Server, node.Port)
+	}
+
+	return node, nil
+}
+
+// parseXrayOutbound 将 Xray/V2Ray 出站转换为 ProxyNode
+func parseXrayOutbound(outbound XrayOutbound) (*ProxyNode, error) {
+	node := &ProxyNode{
+		Name: outbound.Tag,
+		UDP:  true,
+	}
+
+	switch outbound.Protocol {
+	case "vmess", "vless":
+		if len(outbound.Settings.Vnext) == 0 || len(outbound.Settings.Vnext[0].Users) == 0 {
+			return nil, fmt.Errorf("缺少vnext服务器或用户配置")
+		}
+		server := outbound.Settings.Vnext[0]
+		user := server.Users[0]
+		node.Type = outbound.Protocol
+		node.Server = server.Address
+		node.Port = server.Port
+		node.UUID = user.ID
+		if outbound.Protocol == "vmess" {
+			node.AlterID = user.AlterID
+			node.Cipher = user.Security
+			if node.Cipher == "" {
+				node.Cipher = "auto"
+			}
+		} else {
+			node.Password = user.ID
+			node.Flow = user.Flow
+		}
+		if node.UUID == "" {
+			return nil, fmt.Errorf("缺少UUID")
+		}
+	case "trojan", "shadowsocks", "hysteria2":
+		if len(outbound.Settings.Servers) == 0 {
+			return nil, fmt.Errorf("缺少servers配置")
+		}
+		server := outbound.Settings.Servers[0]
+		node.Server = server.Address
+		node.Port = server.Port
+		node.Password = server.Password
+		switch outbound.Protocol {
+		case "trojan", "hysteria2":
+			node.Type = outbound.Protocol
+		default:
+			node.Type = "ss"
+			node.Cipher = server.Method
+		}
+		if node.Password == "" {
+			return nil, fmt.Errorf("缺少密码")
+		}
+	default:
+		return nil, fmt.Errorf("不支持的出站协议: %s", outbound.Protocol)
+	}
+
+	if node.Server == "" || node.Port <= 0 {
+		return nil, fmt.Errorf("缺少服务器地址或端口")
+	}
+
+	tlsEnabled := node.Type == "trojan" || node.Type == "hysteria2"
+	node.Network = "tcp"
+
+	if stream := outbound.StreamSettings; stream != nil {
+		switch stream.Security {
+		case "tls":
+			tlsEnabled = true
+			if tls := stream.TLSSettings; tls != nil {
+				node.SNI = tls.ServerName
+				node.SkipCertVerify = tls.AllowInsecure
+				node.ALPN = tls.ALPN
+				node.Fingerprint = tls.Fingerprint
+			}
+		case "reality":
+			tlsEnabled = true
+			reality := stream.RealitySettings
+			if reality == nil || reality.PublicKey == "" {
+				return nil, fmt.Errorf("Reality配置缺少公钥(publicKey)")
+			}
+			node.SNI = reality.ServerName
+			node.Fingerprint = reality.Fingerprint
+			node.RealityOpts = &struct {
+				PublicKey string `yaml:"public-key"`
+				ShortID   string `yaml:"short-id,omitempty"`
+				SpiderX   string `yaml:"-"`
+			}{
+				PublicKey: reality.PublicKey,
+				ShortID:   reality.ShortID,
+				SpiderX:   reality.SpiderX,
+			}
+			if node.Fingerprint == "" {
+				node.Fingerprint = "chrome"
+			}
+		}
+
+		switch stream.Network {
+		case "ws":
+			node.Network = "ws"
+			path, headers := "", map[string]string{}
+			if ws := stream.WSSettings; ws != nil {
+				path = ws.Path
+				for key, value := range ws.Headers {
+					headers[key] = value
+				}
+				if ws.Host != "" {
+					headers["Host"] = ws.Host
+				}
+			}
+			if headers["Host"] == "" {
+				headers["Host"] = node.Server
+			}
+			node.WSOpts = &struct {
+				Path    string            `yaml:"path"`
+				Headers map[string]string `yaml:"headers,omitempty"`
+			}{
+				Path:    path,
+				Headers: headers,
+			}
+		case "grpc":
+			node.Network = "grpc"
+			serviceName, mode := "", "gun"
+			if grpc := stream.GRPCSettings; grpc != nil {
+				serviceName = grpc.ServiceName
+				if grpc.MultiMode {
+					mode = "multi"
+				}
+			}
+			node.GRPCopts = &struct {
+				ServiceName string `yaml:"service-name,omitempty"`
+				Mode        string `yaml:"mode,omitempty"`
+			}{
+				ServiceName: serviceName,
+				Mode:        mode,
+			}
+		case "h2", "http":
+			node.Network = "h2"
+			host, path := node.Server, ""
+			if httpSettings := stream.HTTPSettings; httpSettings != nil {
+				if len(httpSettings.Host) > 0 {
+					host = httpSettings.Host[0]
+				}
+				path = httpSettings.Path
+			}
+			node.HTTPOpts = &struct {
+				Method  string            `yaml:"method,omitempty"`
+				Headers map[string]string `yaml:"headers,omitempty"`
+				Path    string            `yaml:"path,omitempty"`
+			}{
+				Method: "GET",
+				Path:   path,
+				Headers: map[string]string{
+					"Host": host,
+				},
+			}
+		case "", "tcp", "raw":
+		default:
+			return nil, fmt.Errorf("不支持的传输类型: %s", stream.Network)
+		}
+	}
+
+	// hysteria2 基于 QUIC，不使用 streamSettings 中的传输层
+	if node.Type == "hysteria2" && node.Network != "tcp" {
+		return nil, fmt.Errorf("hysteria2 不支持 %s 传输", node.Network)
+	}
+
+	switch node.Type {
+	case "ss":
+		node.Network = ""
+	case "hysteria2":
+		node.Network = ""
+		node.TLS = &tlsEnabled
+	default:
+		node.TLS = &tlsEnabled
+	}
+
+	if node.Name == "" {
+		node.Name = fmt.Sprintf("%s-%s:%d", node.Type, node.Server, node.Port)
+	}
+
+	return node, nil
+}
@@ -53,7 +53,7 @@ func main() {
 		subscriptionDir = "/app/subscriptions/"
 	}
 	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir(frontendDir))))
-	mux.Handle("/subscriptions/", http.StripPrefix("/subscriptions/", http.FileServer(http.Dir(subscriptionDir))))
+	mux.HandleFunc("/subscriptions/", SubscriptionHandler)
 
 	// 公开路由（无需认证）
 	mux.HandleFunc("/", RootHandler)
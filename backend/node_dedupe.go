@@ -0,0 +1,122 @@
+// backend/node_dedupe.go
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MergedNode 因与其他节点配置相同而被合并的节点
+type MergedNode struct {
+	Name       string `json:"name"`
+	MergedInto string `json:"mergedInto"` // 保留的节点名称
+}
+
+// RenamedNode 因名称冲突而添加后缀的节点
+type RenamedNode struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// nodeConfigKey 返回节点的连接配置标识，服务器、端口、协议、凭据与传输层均相同的节点视为同一节点
+func nodeConfigKey(node ProxyNode) string {
+	var path, host, serviceName, grpcMode string
+	switch {
+	case node.WSOpts != nil:
+		path, host = node.WSOpts.Path, node.WSOpts.Headers["Host"]
+	case node.HTTPOpts != nil:
+		path, host = node.HTTPOpts.Path, node.HTTPOpts.Headers["Host"]
+	case node.GRPCopts != nil:
+		serviceName, grpcMode = node.GRPCopts.ServiceName, node.GRPCopts.Mode
+	}
+
+	var publicKey, shortID string
+	if node.RealityOpts != nil {
+		publicKey, shortID = node.RealityOpts.PublicKey, node.RealityOpts.ShortID
+	}
+
+	network := node.Network
+	if network == "" {
+		network = "tcp"
+	}
+	plugin, pluginOpts := "", ""
+	if node.Plugin != "" {
+		plugin, pluginOpts = formatShadowsocksPlugin(node.Plugin, node.PluginOpts)
+	}
+
+	return strings.Join([]string{
+		node.Type,
+		strings.ToLower(node.Server),
+		strconv.Itoa(node.Port),
+		node.Ports,
+		node.UUID,
+		strconv.Itoa(node.AlterID),
+		node.Password,
+		node.Cipher,
+		plugin,
+		pluginOpts,
+		network,
+		path,
+		host,
+		serviceName,
+		grpcMode,
+		strconv.FormatBool(node.TLS != nil && *node.TLS),
+		node.SNI,
+		node.Flow,
+		publicKey,
+		shortID,
+		node.Obfs,
+		node.ObfsPassword,
+	}, "\x00")
+}
+
+// DedupeNodes 移除配置完全相同的节点，保留最先出现的一个，返回保留的节点与被合并的节点
+func DedupeNodes(nodes []ProxyNode) ([]ProxyNode, []MergedNode) {
+	kept := make([]ProxyNode, 0, len(nodes))
+	keptNames := make(map[string]string)
+	var merged []MergedNode
+
+	for _, node := range nodes {
+		key := nodeConfigKey(node)
+		if name, ok := keptNames[key]; ok {
+			merged = append(merged, MergedNode{Name: node.Name, MergedInto: name})
+			continue
+		}
+		keptNames[key] = node.Name
+		kept = append(kept, node)
+	}
+	return kept, merged
+}
+
+// DisambiguateNames 为重名节点添加序号后缀 (如 "HK 01 2")，最先出现的节点保持原名称
+// reserved 为代理组等已占用的名称，使用这些名称的节点全部添加后缀
+func DisambiguateNames(nodes []ProxyNode, reserved []string) ([]ProxyNode, []RenamedNode) {
+	used := make(map[string]bool, len(nodes)+len(reserved))
+	seen := make(map[string]bool, len(nodes)+len(reserved))
+	for _, name := range reserved {
+		used[name] = true
+		seen[name] = true
+	}
+	for _, node := range nodes {
+		used[node.Name] = true
+	}
+
+	result := make([]ProxyNode, len(nodes))
+	var renamed []RenamedNode
+	for i, node := range nodes {
+		if seen[node.Name] {
+			// 后缀名称也可能已被其他节点使用，依次尝试直到不冲突
+			name := node.Name
+			for suffix := 2; used[name]; suffix++ {
+				name = fmt.Sprintf("%s %d", node.Name, suffix)
+			}
+			used[name] = true
+			renamed = append(renamed, RenamedNode{From: node.Name, To: name})
+			node.Name = name
+		}
+		seen[node.Name] = true
+		result[i] = node
+	}
+	return result, renamed
+}
@@ -0,0 +1,39 @@
+// backend/node_dedupe_test.go
+package main
+
+import "testing"
+
+func TestNodeConfigKey(t *testing.T) {
+	base := func() ProxyNode {
+		node := ProxyNode{
+			Name:    "HK 01",
+			Type:    "vmess",
+			Server:  "hk.example.com",
+			Port:    443,
+			UUID:    "b831381d-6324-4d53-ad4f-8cda48b30811",
+			Network: "grpc",
+		}
+		node.GRPCopts = &struct {
+			ServiceName string `yaml:"service-name,omitempty"`
+			Mode        string `yaml:"mode,omitempty"`
+		}{ServiceName: "svc", Mode: "gun"}
+		return node
+	}
+
+	renamed := base()
+	renamed.Name = "香港 01"
+	renamed.Server = "HK.example.com"
+	if nodeConfigKey(base()) != nodeConfigKey(renamed) {
+		t.Error("名称与服务器大小写不同的节点应视为同一节点")
+	}
+
+	alterID := base()
+	alterID.AlterID = 64
+	multi := base()
+	multi.GRPCopts.Mode = "multi"
+	for name, node := range map[string]ProxyNode{"alterId": alterID, "grpc mode": multi} {
+		if nodeConfigKey(base()) == nodeConfigKey(node) {
+			t.Errorf("%s 不同的节点不应被合并", name)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// backend/node_filter.go
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// NodeFilter 节点过滤条件，所有条件同时满足的节点才会保留
+type NodeFilter struct {
+	Include   string   `json:"include"`   // 节点名称需匹配的正则表达式
+	Exclude   string   `json:"exclude"`   // 节点名称不能匹配的正则表达式，用于去除 "剩余流量"、"到期时间" 等信息节点
+	Protocols []string `json:"protocols"` // 允许的协议类型，为空时不限制
+	Ports     string   `json:"ports"`     // 允许的端口或端口范围，以逗号分隔，如 443,8443,2000-3000
+}
+
+// ExcludedNode 被过滤掉的节点及原因
+type ExcludedNode struct {
+	Name   string `json:"name"`
+	Server string `json:"server"`
+	Reason string `json:"reason"`
+}
+
+// portRange 闭区间端口范围
+type portRange struct {
+	low, high int
+}
+
+// nodeFilterMatcher 编译后的节点过滤条件
+type nodeFilterMatcher struct {
+	include   *regexp.Regexp
+	exclude   *regexp.Regexp
+	protocols map[string]bool
+	ports     []portRange
+}
+
+// compileNodeFilter 校验并编译节点过滤条件
+func compileNodeFilter(filter NodeFilter) (*nodeFilterMatcher, error) {
+	matcher := &nodeFilterMatcher{}
+
+	var err error
+	if matcher.include, err = compileGroupFilter(filter.Include); err != nil {
+		return nil, fmt.Errorf("包含规则正则表达式无效: %v", err)
+	}
+	if matcher.exclude, err = compileGroupFilter(filter.Exclude); err != nil {
+		return nil, fmt.Errorf("排除规则正则表达式无效: %v", err)
+	}
+
+	for _, protocol := range filter.Protocols {
+		protocol = strings.ToLower(strings.TrimSpace(protocol))
+		if protocol == "" {
+			continue
+		}
+		if !isSupportedProtocol(protocol) {
+			return nil, fmt.Errorf("不支持的协议类型: %s", protocol)
+		}
+		if matcher.protocols == nil {
+			matcher.protocols = make(map[string]bool)
+		}
+		matcher.protocols[protocol] = true
+	}
+
+	for _, spec := range strings.Split(filter.Ports, ",") {
+		if spec = strings.TrimSpace(spec); spec == "" {
+			continue
+		}
+		low, high, isRange := strings.Cut(spec, "-")
+		lowPort, err := parseRulePort(low)
+		if err != nil {
+			return nil, err
+		}
+		highPort := lowPort
+		if isRange {
+			if highPort, err = parseRulePort(high); err != nil {
+				return nil, err
+			}
+			if lowPort > highPort {
+				return nil, fmt.Errorf("端口范围 %s 无效: 起始端口大于结束端口", spec)
+			}
+		}
+		matcher.ports = append(matcher.ports, portRange{low: lowPort, high: highPort})
+	}
+
+	return matcher, nil
+}
+
+// reject 返回节点被过滤的原因，节点应保留时返回空字符串
+func (m *nodeFilterMatcher) reject(node ProxyNode) string {
+	if m.exclude != nil && m.exclude.MatchString(node.Name) {
+		return fmt.Sprintf("名称匹配排除规则 %s", m.exclude)
+	}
+	if m.include != nil && !m.include.MatchString(node.Name) {
+		return fmt.Sprintf("名称不匹配包含规则 %s", m.include)
+	}
+	if m.protocols != nil && !m.protocols[node.Type] {
+		return fmt.Sprintf("协议 %s 不在允许列表中", node.Type)
+	}
+	if len(m.ports) > 0 {
+		for _, ports := range m.ports {
+			if node.Port >= ports.low && node.Port <= ports.high {
+				return ""
+			}
+		}
+		return fmt.Sprintf("端口 %d 不在允许范围内", node.Port)
+	}
+	return ""
+}
+
+// FilterNodes 按过滤条件筛选节点，返回保留的节点与被过滤的节点
+func FilterNodes(nodes []ProxyNode, filter NodeFilter) ([]ProxyNode, []ExcludedNode, error) {
+	matcher, err := compileNodeFilter(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kept := make([]ProxyNode, 0, len(nodes))
+	var excluded []ExcludedNode
+	for _, node := range nodes {
+		if reason := matcher.reject(node); reason != "" {
+			excluded = append(excluded, ExcludedNode{Name: node.Name, Server: node.Server, Reason: reason})
+			continue
+		}
+		kept = append(kept, node)
+	}
+	return kept, excluded, nil
+}
@@ -0,0 +1,66 @@
+// backend/node_filter_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilterNodes(t *testing.T) {
+	nodes := []ProxyNode{
+		{Name: "香港 01", Type: "vmess", Port: 443},
+		{Name: "香港 02 游戏", Type: "trojan", Port: 8443},
+		{Name: "日本 01", Type: "ss", Port: 8388},
+		{Name: "剩余流量: 10GB", Type: "ss", Port: 443},
+		{Name: "美国 01", Type: "hysteria2", Port: 2500},
+	}
+
+	tests := []struct {
+		name   string
+		filter NodeFilter
+		kept   string
+		reason string // 第一个被过滤节点的原因需包含的内容
+	}{
+		{"no filter", NodeFilter{}, "香港 01,香港 02 游戏,日本 01,剩余流量: 10GB,美国 01", ""},
+		{"include", NodeFilter{Include: "香港|日本"}, "香港 01,香港 02 游戏,日本 01", "不匹配包含规则"},
+		{"exclude", NodeFilter{Exclude: "剩余|到期"}, "香港 01,香港 02 游戏,日本 01,美国 01", "匹配排除规则"},
+		// 排除规则优先于包含规则
+		{"exclude wins over include", NodeFilter{Include: "香港", Exclude: "游戏"}, "香港 01", "匹配排除规则"},
+		{"case sensitive by default", NodeFilter{Include: "hk"}, "", "不匹配包含规则"},
+		{"protocols", NodeFilter{Protocols: []string{" VMess ", "trojan", ""}}, "香港 01,香港 02 游戏", "协议 ss 不在允许列表中"},
+		{"ports", NodeFilter{Ports: "443, 2000-3000"}, "香港 01,剩余流量: 10GB,美国 01", "端口 8443 不在允许范围内"},
+		{"combined", NodeFilter{Include: "01", Protocols: []string{"ss", "hysteria2"}, Ports: "8000-9000"}, "日本 01", "协议 vmess 不在允许列表中"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, excluded, err := FilterNodes(nodes, tt.filter)
+			if err != nil {
+				t.Fatalf("FilterNodes: %v", err)
+			}
+			if names := strings.Join(proxyNamesOf(kept), ","); names != tt.kept {
+				t.Errorf("kept = %s, want %s", names, tt.kept)
+			}
+			if len(kept)+len(excluded) != len(nodes) {
+				t.Errorf("kept %d + excluded %d != %d nodes", len(kept), len(excluded), len(nodes))
+			}
+			if tt.reason != "" && (len(excluded) == 0 || !strings.Contains(excluded[0].Reason, tt.reason)) {
+				t.Errorf("excluded = %+v, want reason containing %q", excluded, tt.reason)
+			}
+		})
+	}
+
+	for _, filter := range []NodeFilter{
+		{Include: "("},
+		{Exclude: "[a-"},
+		{Protocols: []string{"http"}},
+		{Ports: "0"},
+		{Ports: "443,abc"},
+		{Ports: "3000-2000"},
+		{Ports: "1-70000"},
+	} {
+		if _, _, err := FilterNodes(nodes, filter); err == nil {
+			t.Errorf("FilterNodes(%+v): want error", filter)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// backend/node_rename.go
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RenameOptions 节点重命名选项，先按顺序执行正则替换，再套用命名模板
+type RenameOptions struct {
+	Rules    []RenameRule `json:"rules"`
+	Template string       `json:"template"` // 如 "{flag} {region} {index} {protocol}"，为空时只执行正则替换
+}
+
+// RenameRule 正则查找替换，Replace 中可使用 $1 等捕获组
+type RenameRule struct {
+	Pattern string `json:"pattern"`
+	Replace string `json:"replace"`
+}
+
+// 命名模板支持的变量
+const (
+	renameVarName       = "name"       // 正则替换后的原名称
+	renameVarRegion     = "region"     // 地区代码，如 JP
+	renameVarRegionName = "regionName" // 地区中文名称，如 日本
+	renameVarFlag       = "flag"       // 旗帜 emoji
+	renameVarProtocol   = "protocol"   // 协议名称，如 VLESS
+	renameVarIndex      = "index"      // 同名节点内的序号，至少两位
+	renameVarServer     = "server"     // 服务器地址
+	renameVarLatency    = "latency"    // 检测延迟，如 120ms，未检测时为空
+)
+
+// 无法识别地区时使用的占位
+const (
+	unknownRegionCode = "Other"
+	unknownRegionName = "其他"
+	unknownRegionFlag = "🌐"
+)
+
+// renameVariablePattern 匹配模板中的 {变量}
+var renameVariablePattern = regexp.MustCompile(`\{([A-Za-z]+)\}`)
+
+// renameSpacePattern 变量为空时会留下多余的空白，渲染后合并
+var renameSpacePattern = regexp.MustCompile(`\s{2,}`)
+
+// protocolDisplayNames 协议在节点名称中的写法
+var protocolDisplayNames = map[string]string{
+	"vmess":     "VMess",
+	"vless":     "VLESS",
+	"ss":        "SS",
+	"trojan":    "Trojan",
+	"hysteria2": "Hysteria2",
+	"tuic":      "TUIC",
+}
+
+// compiledRenameRule 编译后的正则替换规则
+type compiledRenameRule struct {
+	pattern *regexp.Regexp
+	replace string
+}
+
+// compileRenameOptions 校验并编译重命名选项
+func compileRenameOptions(options RenameOptions) ([]compiledRenameRule, error) {
+	rules := make([]compiledRenameRule, 0, len(options.Rules))
+	for i, rule := range options.Rules {
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("第 %d 条替换规则缺少正则表达式", i+1)
+		}
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 条替换规则的正则表达式无效: %v", i+1, err)
+		}
+		rules = append(rules, compiledRenameRule{pattern: pattern, replace: rule.Replace})
+	}
+
+	for _, match := range renameVariablePattern.FindAllStringSubmatch(options.Template, -1) {
+		switch match[1] {
+		case renameVarName, renameVarRegion, renameVarRegionName, renameVarFlag,
+			renameVarProtocol, renameVarIndex, renameVarServer, renameVarLatency:
+		default:
+			return nil, fmt.Errorf("命名模板中的变量 {%s} 不存在", match[1])
+		}
+	}
+	return rules, nil
+}
+
+// RenameNodes 按重命名选项生成新的节点名称，latencies 为节点检测延迟 (以 nodeIdentity 为键)，可为 nil
+// {index} 在除序号外渲染结果相同的节点之间编号，因此 "{flag} {region} {index}" 会得到 JP 01、JP 02、US 01 这样的名称
+func RenameNodes(nodes []ProxyNode, options RenameOptions, latencies map[nodeKey]int) ([]ProxyNode, error) {
+	if len(options.Rules) == 0 && options.Template == "" {
+		return nodes, nil
+	}
+	rules, err := compileRenameOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	// 第一遍渲染除序号以外的部分，统计每个名称需要的序号
+	const indexPlaceholder = "\x00"
+	rendered := make([]string, len(nodes))
+	counts := make(map[string]int)
+	for i, node := range nodes {
+		name := node.Name
+		for _, rule := range rules {
+			name = rule.pattern.ReplaceAllString(name, rule.replace)
+		}
+		name = strings.TrimSpace(name)
+
+		if options.Template != "" {
+			variables := renameVariables(node, name, latencies)
+			variables[renameVarIndex] = indexPlaceholder
+			name = renameVariablePattern.ReplaceAllStringFunc(options.Template, func(match string) string {
+				return variables[match[1:len(match)-1]]
+			})
+			name = strings.TrimSpace(renameSpacePattern.ReplaceAllString(name, " "))
+		}
+		rendered[i] = name
+		counts[name]++
+	}
+
+	// 第二遍填入序号
+	renamed := make([]ProxyNode, len(nodes))
+	indexes := make(map[string]int)
+	for i, node := range nodes {
+		name := rendered[i]
+		if strings.Contains(name, indexPlaceholder) {
+			indexes[name]++
+			width := len(strconv.Itoa(counts[name]))
+			if width < 2 {
+				width = 2
+			}
+			name = strings.ReplaceAll(name, indexPlaceholder, fmt.Sprintf("%0*d", width, indexes[name]))
+		}
+		// 规则把名称替换为空时保留原名称
+		if name != "" {
+			node.Name = name
+		}
+		renamed[i] = node
+	}
+	return renamed, nil
+}
+
+// renameVariables 计算节点的模板变量 (不含序号)
+func renameVariables(node ProxyNode, name string, latencies map[nodeKey]int) map[string]string {
+	variables := map[string]string{
+		renameVarName:       name,
+		renameVarRegion:     unknownRegionCode,
+		renameVarRegionName: unknownRegionName,
+		renameVarFlag:       unknownRegionFlag,
+		renameVarProtocol:   protocolDisplayNames[node.Type],
+		renameVarServer:     node.Server,
+	}
+	// 地区按原名称识别，正则替换可能已去掉地区信息
+	if region := classifyRegion(node.Name); region != nil {
+		variables[renameVarRegion] = region.Code
+		variables[renameVarRegionName] = region.Name
+		variables[renameVarFlag] = region.Flag()
+	}
+	if latency, ok := latencies[nodeIdentity(node)]; ok && latency > 0 {
+		variables[renameVarLatency] = fmt.Sprintf("%dms", latency)
+	}
+	return variables
+}
+
+// nodeKey 在一次生成中标识节点，用于关联检测结果
+type nodeKey struct {
+	Type   string
+	Server string
+	Port   int
+	Name   string
+}
+
+// nodeIdentity 返回节点的 nodeKey
+func nodeIdentity(node ProxyNode) nodeKey {
+	return nodeKey{Type: node.Type, Server: node.Server, Port: node.Port, Name: node.Name}
+}
+
+// statusLatencies 将检测结果整理为按 nodeIdentity 查询的延迟
+func statusLatencies(statuses []NodeStatus) map[nodeKey]int {
+	latencies := make(map[nodeKey]int, len(statuses))
+	for _, status := range statuses {
+		if status.Status == "online" {
+			latencies[nodeIdentity(status.Node)] = status.Latency
+		}
+	}
+	return latencies
+}
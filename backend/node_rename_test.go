@@ -0,0 +1,87 @@
+// backend/node_rename_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenameNodes(t *testing.T) {
+	nodes := []ProxyNode{
+		{Name: "【机场】东京 01", Type: "vless", Server: "jp1.example.com", Port: 443},
+		{Name: "【机场】Osaka", Type: "vless", Server: "jp2.example.com", Port: 443},
+		{Name: "【机场】HK IPLC", Type: "trojan", Server: "hk.example.com", Port: 443},
+		{Name: "【机场】未知", Type: "ss", Server: "other.example.com", Port: 8388},
+		{Name: "【机场】东京 02", Type: "tuic", Server: "jp3.example.com", Port: 443},
+	}
+	latencies := map[nodeKey]int{nodeIdentity(nodes[0]): 120, nodeIdentity(nodes[2]): 0}
+
+	tests := []struct {
+		name    string
+		options RenameOptions
+		want    string
+	}{
+		{"no options", RenameOptions{}, "【机场】东京 01,【机场】Osaka,【机场】HK IPLC,【机场】未知,【机场】东京 02"},
+		{"rules only", RenameOptions{Rules: []RenameRule{{Pattern: `^【.*?】`}, {Pattern: `(\d+)$`, Replace: "#$1"}}},
+			"东京 #01,Osaka,HK IPLC,未知,东京 #02"},
+		{"rule removes everything", RenameOptions{Rules: []RenameRule{{Pattern: `.*`}}},
+			"【机场】东京 01,【机场】Osaka,【机场】HK IPLC,【机场】未知,【机场】东京 02"},
+		{"flag region index protocol", RenameOptions{Template: "{flag} {region} {index} {protocol}"},
+			"🇯🇵 JP 01 VLESS,🇯🇵 JP 02 VLESS,🇭🇰 HK 01 Trojan,🌐 Other 01 SS,🇯🇵 JP 01 TUIC"},
+		{"region name and index", RenameOptions{Template: "{regionName}-{index}"},
+			"日本-01,日本-02,香港-01,其他-01,日本-03"},
+		{"name after rules", RenameOptions{Rules: []RenameRule{{Pattern: `^【.*?】`}}, Template: "{flag} {name}"},
+			"🇯🇵 东京 01,🇯🇵 Osaka,🇭🇰 HK IPLC,🌐 未知,🇯🇵 东京 02"},
+		// 延迟为空时合并多余的空白
+		{"latency and server", RenameOptions{Template: "{region} {latency} {server}"},
+			"JP 120ms jp1.example.com,JP jp2.example.com,HK hk.example.com,Other other.example.com,JP jp3.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renamed, err := RenameNodes(nodes, tt.options, latencies)
+			if err != nil {
+				t.Fatalf("RenameNodes: %v", err)
+			}
+			if got := strings.Join(proxyNamesOf(renamed), ","); got != tt.want {
+				t.Errorf("names = %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+
+	for _, options := range []RenameOptions{
+		{Template: "{flag} {country}"},
+		{Rules: []RenameRule{{Pattern: ""}}},
+		{Rules: []RenameRule{{Pattern: "("}}},
+	} {
+		if _, err := RenameNodes(nodes, options, nil); err == nil {
+			t.Errorf("RenameNodes(%+v): want error", options)
+		}
+	}
+}
+
+func TestRenameCollisions(t *testing.T) {
+	nodes := []ProxyNode{
+		{Name: "东京 A", Type: "vless"},
+		{Name: "东京 B", Type: "vless"},
+		{Name: "JP 2", Type: "trojan"},
+		{Name: "东京 C", Type: "vless"},
+	}
+
+	// 模板不含 {index} 时多个节点渲染为同一名称，由 DisambiguateNames 添加后缀
+	renamed, err := RenameNodes(nodes, RenameOptions{Rules: []RenameRule{{Pattern: `^东京 \w$`, Replace: "JP"}}}, nil)
+	if err != nil {
+		t.Fatalf("RenameNodes: %v", err)
+	}
+	if got := strings.Join(proxyNamesOf(renamed), ","); got != "JP,JP,JP 2,JP" {
+		t.Fatalf("names = %s", got)
+	}
+
+	renamed, changes := DisambiguateNames(renamed, nil)
+	if got := strings.Join(proxyNamesOf(renamed), ","); got != "JP,JP 3,JP 2,JP 4" {
+		t.Errorf("names = %s, want JP,JP 3,JP 2,JP 4", got)
+	}
+	if len(changes) != 2 || changes[0] != (RenamedNode{From: "JP", To: "JP 3"}) || changes[1] != (RenamedNode{From: "JP", To: "JP 4"}) {
+		t.Errorf("changes = %+v", changes)
+	}
+}
@@ -0,0 +1,125 @@
+// backend/node_sort.go
+package main
+
+import (
+	"fmt"
+	"sort"
+	"unicode"
+)
+
+// 节点排序方式，为空时保持输入顺序
+const (
+	sortByLatency = "latency" // 按检测延迟从低到高，未检测或离线的节点排在最后
+	sortByRegion  = "region"  // 按地区 (与地区分组顺序一致) 再按名称，无法识别地区的节点排在最后
+	sortByName    = "name"    // 按名称自然排序，数字按数值比较 (HK 2 排在 HK 10 之前)
+)
+
+// validateSortBy 校验排序方式
+func validateSortBy(sortBy string, checkNodes bool) error {
+	switch sortBy {
+	case "", sortByName, sortByRegion:
+		return nil
+	case sortByLatency:
+		if !checkNodes {
+			return fmt.Errorf("按延迟排序需要开启节点连通性检测")
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的排序方式: %s", sortBy)
+	}
+}
+
+// SortNodes 按排序方式稳定排序节点，latencies 为节点检测延迟 (以 nodeIdentity 为键)
+// 节点顺序决定了 proxies 与各代理组成员的顺序
+func SortNodes(nodes []ProxyNode, sortBy string, latencies map[nodeKey]int) []ProxyNode {
+	sorted := append([]ProxyNode(nil), nodes...)
+
+	switch sortBy {
+	case sortByLatency:
+		latencyOf := func(node ProxyNode) int {
+			if latency, ok := latencies[nodeIdentity(node)]; ok && latency > 0 {
+				return latency
+			}
+			return int(^uint(0) >> 1)
+		}
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return latencyOf(sorted[i]) < latencyOf(sorted[j])
+		})
+	case sortByRegion:
+		regionOrder := make(map[*Region]int, len(regions))
+		for i, region := range regions {
+			regionOrder[region] = i
+		}
+		orderOf := func(node ProxyNode) int {
+			if region := classifyRegion(node.Name); region != nil {
+				return regionOrder[region]
+			}
+			return len(regions)
+		}
+		sort.SliceStable(sorted, func(i, j int) bool {
+			orderI, orderJ := orderOf(sorted[i]), orderOf(sorted[j])
+			if orderI != orderJ {
+				return orderI < orderJ
+			}
+			return naturalLess(sorted[i].Name, sorted[j].Name)
+		})
+	case sortByName:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return naturalLess(sorted[i].Name, sorted[j].Name)
+		})
+	}
+	return sorted
+}
+
+// naturalLess 自然顺序比较字符串，不区分大小写，连续的数字按数值大小比较
+func naturalLess(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	i, j := 0, 0
+	for i < len(ra) && j < len(rb) {
+		if unicode.IsDigit(ra[i]) && unicode.IsDigit(rb[j]) {
+			startI, startJ := i, j
+			for i < len(ra) && unicode.IsDigit(ra[i]) {
+				i++
+			}
+			for j < len(rb) && unicode.IsDigit(rb[j]) {
+				j++
+			}
+			if cmp := compareDigits(ra[startI:i], rb[startJ:j]); cmp != 0 {
+				return cmp < 0
+			}
+			continue
+		}
+		if lowerI, lowerJ := unicode.ToLower(ra[i]), unicode.ToLower(rb[j]); lowerI != lowerJ {
+			return lowerI < lowerJ
+		}
+		i++
+		j++
+	}
+	if remainingA, remainingB := len(ra)-i, len(rb)-j; remainingA != remainingB {
+		return remainingA < remainingB
+	}
+	// 仅大小写或前导零不同时按原字符串比较，保证顺序确定
+	return a < b
+}
+
+// compareDigits 按数值比较两段数字，忽略前导零，数值相同时前导零少的在前
+func compareDigits(a, b []rune) int {
+	trimmedA, trimmedB := trimLeadingZeros(a), trimLeadingZeros(b)
+	if len(trimmedA) != len(trimmedB) {
+		return len(trimmedA) - len(trimmedB)
+	}
+	for k := range trimmedA {
+		if trimmedA[k] != trimmedB[k] {
+			return int(trimmedA[k]) - int(trimmedB[k])
+		}
+	}
+	return len(a) - len(b)
+}
+
+// trimLeadingZeros 去除数字前导零，全为零时保留一位
+func trimLeadingZeros(digits []rune) []rune {
+	for len(digits) > 1 && digits[0] == '0' {
+		digits = digits[1:]
+	}
+	return digits
+}
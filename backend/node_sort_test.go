@@ -0,0 +1,101 @@
+// backend/node_sort_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSortNodes(t *testing.T) {
+	nodes := []ProxyNode{
+		{Name: "US 10", Server: "us10"},
+		{Name: "hk 2", Server: "hk2"},
+		{Name: "未知 B", Server: "b"},
+		{Name: "HK 10", Server: "hk10"},
+		{Name: "US 2", Server: "us2"},
+		{Name: "未知 A", Server: "a"},
+		{Name: "HK 02", Server: "hk02"},
+		{Name: "JP 1", Server: "jp1"},
+	}
+	latencies := map[nodeKey]int{
+		nodeIdentity(nodes[0]): 80,
+		nodeIdentity(nodes[3]): 80,
+		nodeIdentity(nodes[4]): 30,
+		nodeIdentity(nodes[7]): 0, // 离线节点的延迟为 0
+	}
+
+	tests := []struct {
+		sortBy string
+		want   string
+	}{
+		{"", "US 10,hk 2,未知 B,HK 10,US 2,未知 A,HK 02,JP 1"},
+		// 延迟相同或未检测的节点保持输入顺序
+		{sortByLatency, "US 2,US 10,HK 10,hk 2,未知 B,未知 A,HK 02,JP 1"},
+		// 地区代码区分大小写，"hk 2" 无法识别地区，与其他未识别的节点按名称排在最后
+		{sortByRegion, "HK 02,HK 10,JP 1,US 2,US 10,hk 2,未知 A,未知 B"},
+		{sortByName, "hk 2,HK 02,HK 10,JP 1,US 2,US 10,未知 A,未知 B"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			sorted := SortNodes(nodes, tt.sortBy, latencies)
+			if got := strings.Join(proxyNamesOf(sorted), ","); got != tt.want {
+				t.Errorf("names = %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+	if nodes[0].Name != "US 10" {
+		t.Error("SortNodes modified the input slice")
+	}
+
+	// 同名节点按输入顺序排列
+	duplicates := []ProxyNode{{Name: "HK", Server: "first"}, {Name: "HK", Server: "second"}, {Name: "A", Server: "third"}}
+	var servers []string
+	for _, node := range SortNodes(duplicates, sortByName, nil) {
+		servers = append(servers, node.Server)
+	}
+	if strings.Join(servers, ",") != "third,first,second" {
+		t.Errorf("servers = %v, want third,first,second", servers)
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	// 每组中的前一个应排在后一个之前
+	ordered := [][2]string{
+		{"HK 2", "HK 10"},
+		{"hk 2", "HK 3"},
+		{"HK 1", "HK 01"},
+		{"HK", "HK 1"},
+		{"HK 9", "HKG 1"},
+		{"HK", "hk"},
+		{"node 99999999999999999999", "node 100000000000000000000"},
+	}
+	for _, pair := range ordered {
+		if !naturalLess(pair[0], pair[1]) || naturalLess(pair[1], pair[0]) {
+			t.Errorf("want %q < %q", pair[0], pair[1])
+		}
+	}
+	if naturalLess("HK 1", "HK 1") {
+		t.Error("naturalLess of equal strings should be false")
+	}
+}
+
+func TestValidateSortBy(t *testing.T) {
+	tests := []struct {
+		sortBy     string
+		checkNodes bool
+		valid      bool
+	}{
+		{"", false, true},
+		{sortByName, false, true},
+		{sortByRegion, false, true},
+		{sortByLatency, true, true},
+		{sortByLatency, false, false},
+		{"random", true, false},
+	}
+	for _, tt := range tests {
+		if err := validateSortBy(tt.sortBy, tt.checkNodes); (err == nil) != tt.valid {
+			t.Errorf("validateSortBy(%q, %v) = %v, want valid = %v", tt.sortBy, tt.checkNodes, err, tt.valid)
+		}
+	}
+}
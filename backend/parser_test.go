@@ -0,0 +1,513 @@
+// backend/parser_test.go
+package main
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// assertParseErrors 检查格式错误的链接返回错误，而不是 panic 或返回节点
+func assertParseErrors(t *testing.T, parse func(string) (*ProxyNode, error), links []string) {
+	t.Helper()
+	for _, link := range links {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%q: panic: %v", link, r)
+				}
+			}()
+			if node, err := parse(link); err == nil {
+				t.Errorf("%q: node = %+v, want error", link, node)
+			}
+		}()
+	}
+}
+
+func TestParseShadowsocksLink(t *testing.T) {
+	userInfo := "aes-128-gcm:pass/word+1"
+	padded := base64.URLEncoding.EncodeToString([]byte(userInfo))
+	unpadded := base64.RawURLEncoding.EncodeToString([]byte(userInfo))
+	legacy := base64.StdEncoding.EncodeToString([]byte("AES-256-CFB:secret@ss.example.com:8388"))
+
+	tests := []struct {
+		name string
+		link string
+		want ProxyNode
+	}{
+		{"SIP002 padded base64", "ss://" + padded + "@ss.example.com:8388#HK%2001",
+			ProxyNode{Name: "HK 01", Server: "ss.example.com", Port: 8388, Cipher: "aes-128-gcm", Password: "pass/word+1"}},
+		{"SIP002 unpadded base64", "ss://" + unpadded + "@ss.example.com:8388/#HK",
+			ProxyNode{Name: "HK", Server: "ss.example.com", Port: 8388, Cipher: "aes-128-gcm", Password: "pass/word+1"}},
+		{"SIP002 plain userinfo", "ss://2022-blake3-aes-128-gcm:YWJjZA%3D%3D@ss.example.com:443#2022",
+			ProxyNode{Name: "2022", Server: "ss.example.com", Port: 443, Cipher: "2022-blake3-aes-128-gcm", Password: "YWJjZA=="}},
+		{"IPv6 host", "ss://" + padded + "@[2001:db8::1]:8388#v6",
+			ProxyNode{Name: "v6", Server: "2001:db8::1", Port: 8388, Cipher: "aes-128-gcm", Password: "pass/word+1"}},
+		{"legacy", "ss://" + legacy + "#legacy",
+			ProxyNode{Name: "legacy", Server: "ss.example.com", Port: 8388, Cipher: "aes-256-cfb", Password: "secret"}},
+		{"legacy unpadded", "ss://" + base64.RawStdEncoding.EncodeToString([]byte("aes-256-cfb:secret@ss.example.com:8388")),
+			ProxyNode{Name: "SS-ss.example.com:8388", Server: "ss.example.com", Port: 8388, Cipher: "aes-256-cfb", Password: "secret"}},
+		{"obfs plugin", "ss://" + padded + "@ss.example.com:8388/?plugin=obfs-local%3Bobfs%3Dhttp%3Bobfs-host%3Dcdn.example.com#obfs",
+			ProxyNode{Name: "obfs", Server: "ss.example.com", Port: 8388, Cipher: "aes-128-gcm", Password: "pass/word+1",
+				Plugin: "obfs", PluginOpts: map[string]interface{}{"mode": "http", "host": "cdn.example.com"}}},
+		{"v2ray-plugin", "ss://" + padded + "@ss.example.com:443?plugin=v2ray-plugin%3Btls%3Bhost%3Dcdn.example.com%3Bpath%3D%2Fws#v2ray",
+			ProxyNode{Name: "v2ray", Server: "ss.example.com", Port: 443, Cipher: "aes-128-gcm", Password: "pass/word+1",
+				Plugin: "v2ray-plugin", PluginOpts: map[string]interface{}{"mode": "websocket", "tls": true, "host": "cdn.example.com", "path": "/ws"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := parseShadowsocksLink(tt.link)
+			if err != nil {
+				t.Fatalf("parseShadowsocksLink: %v", err)
+			}
+			tt.want.Type = "ss"
+			tt.want.UDP = true
+			if !reflect.DeepEqual(*node, tt.want) {
+				t.Errorf("node = %+v\nwant %+v", *node, tt.want)
+			}
+		})
+	}
+
+	assertParseErrors(t, parseShadowsocksLink, []string{
+		"ss://",
+		"ss://" + padded,
+		"ss://" + padded + "@ss.example.com",
+		"ss://" + padded + "@ss.example.com:port",
+		"ss://" + padded + "@:8388",
+		"ss://" + padded + "@[2001:db8::1:8388",
+		"ss://" + base64.StdEncoding.EncodeToString([]byte("aes-128-gcm@ss.example.com:8388")),
+		"ss://!!!not-base64!!!",
+		"ss://" + padded + "@ss.example.com:8388?plugin=%zz",
+		"trojan://password@example.com:443",
+	})
+}
+
+// transportSummary 以 "network host path service mode" 的形式概括节点的传输层配置
+func transportSummary(node *ProxyNode) string {
+	fields := []string{node.Network, "", "", "", ""}
+	if node.WSOpts != nil {
+		fields[1], fields[2] = node.WSOpts.Headers["Host"], node.WSOpts.Path
+	}
+	if node.GRPCopts != nil {
+		fields[3], fields[4] = node.GRPCopts.ServiceName, node.GRPCopts.Mode
+	}
+	return strings.Join(fields, " ")
+}
+
+func TestParseTrojanLink(t *testing.T) {
+	tests := []struct {
+		name      string
+		link      string
+		server    string
+		port      int
+		sni       string
+		alpn      string
+		skip      bool
+		transport string
+	}{
+		{"tcp", "trojan://pass%40word@trojan.example.com:8443?sni=sni.example.com&alpn=h2%2Chttp%2F1.1#tcp",
+			"trojan.example.com", 8443, "sni.example.com", "h2,http/1.1", false, "tcp    "},
+		{"default port and peer", "trojan://pass%40word@trojan.example.com?peer=peer.example.com&allowInsecure=1",
+			"trojan.example.com", 443, "peer.example.com", "", true, "tcp    "},
+		{"IPv6 host", "trojan://pass%40word@[2001:db8::1]:443?sni=v6.example.com#v6",
+			"2001:db8::1", 443, "v6.example.com", "", false, "tcp    "},
+		{"ws", "trojan://pass%40word@trojan.example.com:443?type=ws&host=cdn.example.com&path=%2Fws%3Fed%3D2048#ws",
+			"trojan.example.com", 443, "", "", false, "ws cdn.example.com /ws?ed=2048  "},
+		{"ws host from sni", "trojan://pass%40word@trojan.example.com:443?type=ws&sni=sni.example.com&path=%2Fws",
+			"trojan.example.com", 443, "sni.example.com", "", false, "ws sni.example.com /ws  "},
+		{"grpc", "trojan://pass%40word@trojan.example.com:443?type=grpc&serviceName=svc#grpc",
+			"trojan.example.com", 443, "", "", false, "grpc   svc gun"},
+		{"grpc multi", "trojan://pass%40word@trojan.example.com:443?type=grpc&serviceName=svc&mode=multi",
+			"trojan.example.com", 443, "", "", false, "grpc   svc multi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := parseTrojanLink(tt.link)
+			if err != nil {
+				t.Fatalf("parseTrojanLink: %v", err)
+			}
+			if node.Type != "trojan" || node.Password != "pass@word" || node.TLS == nil || !*node.TLS {
+				t.Errorf("type = %s, password = %q, tls = %v", node.Type, node.Password, node.TLS)
+			}
+			if node.Server != tt.server || node.Port != tt.port || node.SNI != tt.sni || node.SkipCertVerify != tt.skip {
+				t.Errorf("server = %s, port = %d, sni = %s, skip-cert-verify = %v", node.Server, node.Port, node.SNI, node.SkipCertVerify)
+			}
+			if alpn := strings.Join(node.ALPN, ","); alpn != tt.alpn {
+				t.Errorf("alpn = %q, want %q", alpn, tt.alpn)
+			}
+			if transport := transportSummary(node); transport != tt.transport {
+				t.Errorf("transport = %q, want %q", transport, tt.transport)
+			}
+		})
+	}
+
+	assertParseErrors(t, parseTrojanLink, []string{
+		"trojan://",
+		"trojan://trojan.example.com:443",
+		"trojan://password@:443",
+		"trojan://password@trojan.example.com:port",
+		"trojan://password@[2001:db8::1:443",
+		"ss://password@trojan.example.com:443",
+	})
+}
+
+func TestParseHysteria2Link(t *testing.T) {
+	tests := []struct {
+		name   string
+		link   string
+		server string
+		port   int
+		ports  string
+		check  func(node *ProxyNode) bool
+	}{
+		{"single port", "hysteria2://pass%3Aword@hy2.example.com:8443/?sni=sni.example.com&insecure=1#hy2",
+			"hy2.example.com", 8443, "", func(node *ProxyNode) bool {
+				return node.Password == "pass:word" && node.SNI == "sni.example.com" && node.SkipCertVerify && node.Name == "hy2"
+			}},
+		{"default port", "hy2://pass@hy2.example.com/?obfs=salamander&obfs-password=obfs", "hy2.example.com", 443, "",
+			func(node *ProxyNode) bool { return node.Obfs == "salamander" && node.ObfsPassword == "obfs" }},
+		{"port hopping", "hy2://pass@hy2.example.com:443,20000-30000/?pinSHA256=AB:CD:EF", "hy2.example.com", 443, "443,20000-30000",
+			func(node *ProxyNode) bool { return node.CertFingerprint == "abcdef" }},
+		{"mport", "hy2://pass@hy2.example.com/?mport=20000-30000&obfs=none", "hy2.example.com", 20000, "20000-30000",
+			func(node *ProxyNode) bool { return node.Obfs == "" }},
+		{"IPv6 host", "hy2://pass@[2001:db8::1]:443,5000-6000/?alpn=h3", "2001:db8::1", 443, "443,5000-6000",
+			func(node *ProxyNode) bool { return len(node.ALPN) == 1 && node.ALPN[0] == "h3" }},
+		{"IPv6 host without port", "hy2://pass@[2001:db8::1]/", "2001:db8::1", 443, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := parseHysteria2Link(tt.link)
+			if err != nil {
+				t.Fatalf("parseHysteria2Link: %v", err)
+			}
+			if node.Type != "hysteria2" || node.Server != tt.server || node.Port != tt.port || node.Ports != tt.ports {
+				t.Errorf("type = %s, server = %s, port = %d, ports = %q", node.Type, node.Server, node.Port, node.Ports)
+			}
+			if tt.check != nil && !tt.check(node) {
+				t.Errorf("node = %+v", *node)
+			}
+		})
+	}
+
+	assertParseErrors(t, parseHysteria2Link, []string{
+		"hy2://",
+		"hy2://pass@:443",
+		"hy2://pass@hy2.example.com:0",
+		"hy2://pass@hy2.example.com:70000",
+		"hy2://pass@hy2.example.com:6000-5000",
+		"hy2://pass@hy2.example.com:443,abc",
+		"hy2://pass@hy2.example.com/?mport=1-2-3",
+		"hy2://pass@[2001:db8::1:443",
+		"hy2://pass@[2001:db8::1]x/",
+		"hy2://pass@hy2.example.com:443/?sni=%zz",
+		"hysteria://pass@hy2.example.com:443",
+	})
+}
+
+func TestParseTUICLink(t *testing.T) {
+	const uuid = "b831381d-6324-4d53-ad4f-8cda48b30811"
+	tests := []struct {
+		name     string
+		link     string
+		server   string
+		port     int
+		password string
+		skip     bool
+		options  string // "congestion_control udp_relay_mode alpn"
+	}{
+		{"full", "tuic://" + uuid + ":pass%3Aword@tuic.example.com:8443?sni=sni.example.com&congestion_control=bbr&udp_relay_mode=quic&alpn=h3,spdy/3.1#tuic",
+			"tuic.example.com", 8443, "pass:word", false, "bbr quic h3,spdy/3.1"},
+		{"allowInsecure", "tuic://" + uuid + ":pass@tuic.example.com:443?allowInsecure=1",
+			"tuic.example.com", 443, "pass", true, "  "},
+		{"allow_insecure", "tuic://" + uuid + ":pass@tuic.example.com:443?allow_insecure=true",
+			"tuic.example.com", 443, "pass", true, "  "},
+		{"IPv6 host", "tuic://" + uuid + ":pass@[2001:db8::1]:443?congestion_control=cubic#v6",
+			"2001:db8::1", 443, "pass", false, "cubic  "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := parseTUICLink(tt.link)
+			if err != nil {
+				t.Fatalf("parseTUICLink: %v", err)
+			}
+			if node.Type != "tuic" || node.UUID != uuid || node.Password != tt.password || node.Server != tt.server || node.Port != tt.port {
+				t.Errorf("type = %s, uuid = %s, password = %q, server = %s, port = %d", node.Type, node.UUID, node.Password, node.Server, node.Port)
+			}
+			if node.SkipCertVerify != tt.skip {
+				t.Errorf("skip-cert-verify = %v, want %v", node.SkipCertVerify, tt.skip)
+			}
+			if options := node.CongestionControl + " " + node.UDPRelayMode + " " + strings.Join(node.ALPN, ","); options != tt.options {
+				t.Errorf("options = %q, want %q", options, tt.options)
+			}
+		})
+	}
+
+	assertParseErrors(t, parseTUICLink, []string{
+		"tuic://",
+		"tuic://" + uuid + "@tuic.example.com:443",
+		"tuic://:pass@tuic.example.com:443",
+		"tuic://" + uuid + ":pass@tuic.example.com",
+		"tuic://" + uuid + ":pass@:443",
+		"tuic://" + uuid + ":pass@tuic.example.com:port",
+		"tuic://" + uuid + ":pass@[2001:db8::1:443",
+		"hy2://" + uuid + ":pass@tuic.example.com:443",
+	})
+}
+
+func TestParseVLESSRealityLink(t *testing.T) {
+	const uuid = "b831381d-6324-4d53-ad4f-8cda48b30811"
+	const publicKey = "jNXHt1yRo0vDuchQlIP6Z0ZvjT3KtzVI-T4E7RoLJS0"
+	tests := []struct {
+		name        string
+		link        string
+		server      string
+		fingerprint string
+		shortID     string
+		spiderX     string
+		transport   string
+	}{
+		{"vision", "vless://" + uuid + "@reality.example.com:443?security=reality&sni=www.microsoft.com&fp=safari&pbk=" + publicKey +
+			"&sid=6ba85179e30d4fc2&spx=%2Fpath%3Fq%3D1&flow=xtls-rprx-vision&type=tcp#reality",
+			"reality.example.com", "safari", "6ba85179e30d4fc2", "/path?q=1", "tcp    "},
+		{"default fingerprint", "vless://" + uuid + "@reality.example.com:443?security=reality&sni=www.microsoft.com&pbk=" + publicKey,
+			"reality.example.com", "chrome", "", "", "tcp    "},
+		{"IPv6 host", "vless://" + uuid + "@[2001:db8::1]:443?security=reality&pbk=" + publicKey + "&sid=ab",
+			"2001:db8::1", "chrome", "ab", "", "tcp    "},
+		{"grpc", "vless://" + uuid + "@reality.example.com:443?security=reality&pbk=" + publicKey + "&type=grpc&serviceName=svc",
+			"reality.example.com", "chrome", "", "", "grpc   svc gun"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := parseVLESSLink(tt.link)
+			if err != nil {
+				t.Fatalf("parseVLESSLink: %v", err)
+			}
+			if node.Type != "vless" || node.UUID != uuid || node.Server != tt.server || node.Port != 443 || node.TLS == nil || !*node.TLS {
+				t.Errorf("type = %s, uuid = %s, server = %s, port = %d, tls = %v", node.Type, node.UUID, node.Server, node.Port, node.TLS)
+			}
+			if node.RealityOpts == nil {
+				t.Fatal("reality-opts missing")
+			}
+			reality := *node.RealityOpts
+			if reality.PublicKey != publicKey || reality.ShortID != tt.shortID || reality.SpiderX != tt.spiderX || node.Fingerprint != tt.fingerprint {
+				t.Errorf("reality = %+v, fingerprint = %s", reality, node.Fingerprint)
+			}
+			if transport := transportSummary(node); transport != tt.transport {
+				t.Errorf("transport = %q, want %q", transport, tt.transport)
+			}
+		})
+	}
+
+	assertParseErrors(t, parseVLESSLink, []string{
+		"vless://",
+		"vless://" + uuid + "@reality.example.com:443?security=reality&sni=www.microsoft.com",
+		"vless://@reality.example.com:443?security=reality&pbk=" + publicKey,
+		"vless://" + uuid + "@reality.example.com?security=reality&pbk=" + publicKey,
+		"vless://" + uuid + "@:443?security=reality&pbk=" + publicKey,
+		"vless://" + uuid + "@[2001:db8::1:443?security=reality&pbk=" + publicKey,
+		"vmess://" + uuid + "@reality.example.com:443",
+	})
+}
+
+func TestParseBase64Subscription(t *testing.T) {
+	content := testTrojanLink + "\n" + testSSLink + "\n"
+	padded := base64.StdEncoding.EncodeToString([]byte(content))
+	if !strings.HasSuffix(padded, "=") {
+		t.Fatal("test content must need padding")
+	}
+	// 按 76 个字符换行的订阅内容
+	var wrapped strings.Builder
+	for i := 0; i < len(padded); i += 76 {
+		wrapped.WriteString(padded[i:min(i+76, len(padded))] + "\r\n")
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		names string
+	}{
+		{"padded", padded, "HK 01,JP 01"},
+		{"unpadded", base64.RawStdEncoding.EncodeToString([]byte(content)), "HK 01,JP 01"},
+		{"url safe", base64.URLEncoding.EncodeToString([]byte(content)), "HK 01,JP 01"},
+		{"url safe unpadded", base64.RawURLEncoding.EncodeToString([]byte(content)), "HK 01,JP 01"},
+		{"wrapped", wrapped.String(), "HK 01,JP 01"},
+		{"surrounding whitespace", "\n  " + padded + "  \n", "HK 01,JP 01"},
+		{"base64 line among links", testTrojanLink + "\n" + base64.StdEncoding.EncodeToString([]byte(testSSLink)), "HK 01,JP 01"},
+		{"plain links", content, "HK 01,JP 01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := ParseProxyLinks(tt.input)
+			if err != nil {
+				t.Fatalf("ParseProxyLinks: %v", err)
+			}
+			if names := strings.Join(proxyNamesOf(nodes), ","); names != tt.names {
+				t.Errorf("names = %s, want %s", names, tt.names)
+			}
+		})
+	}
+
+	for _, input := range []string{
+		"",
+		"   \n\t",
+		base64.StdEncoding.EncodeToString([]byte("just some text")),
+		base64.StdEncoding.EncodeToString([]byte{0xff, 0xfe, ':', '/', '/'}),
+		padded[:len(padded)-5] + "!!!",
+		"not a link",
+	} {
+		if nodes, err := ParseProxyLinks(input); err == nil {
+			t.Errorf("%q: nodes = %+v, want error", input, nodes)
+		}
+	}
+}
+
+func TestParseClashConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		proxy string // proxies 列表中的一项
+		check func(node ProxyNode) bool
+	}{
+		{"IPv6 server", `{name: v6, type: trojan, server: "2001:db8::1", port: 443, password: secret, sni: v6.example.com}`,
+			func(node ProxyNode) bool {
+				return node.Server == "2001:db8::1" && node.SNI == "v6.example.com" && *node.TLS
+			}},
+		{"string port and numeric password", `{name: ss, type: ss, server: ss.example.com, port: "8388", cipher: aes-128-gcm, password: 123456}`,
+			func(node ProxyNode) bool { return node.Port == 8388 && node.Password == "123456" }},
+		{"ss plugin opts", `{name: obfs, type: ss, server: ss.example.com, port: 8388, cipher: aes-128-gcm, password: secret, plugin: obfs, plugin-opts: {mode: tls, host: cdn.example.com}}`,
+			func(node ProxyNode) bool {
+				return node.Plugin == "obfs" && node.PluginOpts["mode"] == "tls" && node.PluginOpts["host"] == "cdn.example.com"
+			}},
+		{"hysteria2 port hopping", `{name: hy2, type: hysteria2, server: hy2.example.com, port: 443, auth: secret, ports: "443,20000-30000", obfs: salamander, obfs-password: obfs}`,
+			func(node ProxyNode) bool {
+				return node.Password == "secret" && node.Ports == "443,20000-30000" && node.Obfs == "salamander"
+			}},
+		{"vmess legacy ws keys", `{name: ws, type: vmess, server: vmess.example.com, port: 443, uuid: b831381d-6324-4d53-ad4f-8cda48b30811, alterId: 0, tls: true, network: ws, ws-path: /ws, ws-headers: {Host: cdn.example.com}}`,
+			func(node ProxyNode) bool {
+				return node.Cipher == "auto" && transportSummary(&node) == "ws cdn.example.com /ws  "
+			}},
+		{"vless reality grpc", `{name: reality, type: vless, server: vless.example.com, port: 443, uuid: b831381d-6324-4d53-ad4f-8cda48b30811, tls: true, network: grpc, grpc-opts: {grpc-service-name: svc}, reality-opts: {public-key: pbk, short-id: "0123"}}`,
+			func(node ProxyNode) bool {
+				return node.RealityOpts != nil && node.RealityOpts.ShortID == "0123" && transportSummary(&node) == "grpc   svc gun"
+			}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 不支持的节点类型被跳过，不影响其他节点
+			content := "port: 7890\nproxies:\n  - {name: http, type: http, server: http.example.com, port: 8080}\n  - " + tt.proxy + "\n"
+			nodes, err := ParseProxyLinks(content)
+			if err != nil {
+				t.Fatalf("ParseProxyLinks: %v", err)
+			}
+			if len(nodes) != 1 || !tt.check(nodes[0]) {
+				t.Errorf("nodes = %+v", nodes)
+			}
+		})
+	}
+
+	for _, content := range []string{
+		"proxies:\n",
+		"proxies: []\n",
+		"proxies: not a list\n",
+		"proxies:\n  - just a string\n",
+		"proxies:\n  - {name: a, type: trojan, server: [1, 2], port: 443\n",
+		"proxies:\n  - {name: http, type: http, server: http.example.com, port: 8080}\n",
+		"proxies:\n  - {name: a, type: trojan, server: trojan.example.com, port: -1, password: secret}\n",
+		"proxies:\n  - {name: a, type: trojan, server: trojan.example.com, port: abc, password: secret}\n",
+		"proxies:\n  - {name: a, type: ss, server: ss.example.com, port: 8388}\n",
+		"proxies:\n  - {name: a, type: vless, server: vless.example.com, port: 443}\n",
+		"proxies:\n  - {name: a, server: trojan.example.com, port: 443, password: secret}\n",
+	} {
+		if nodes, err := ParseProxyLinks(content); err == nil {
+			t.Errorf("%q: nodes = %+v, want error", content, nodes)
+		}
+	}
+}
+
+func TestParseJSONConfig(t *testing.T) {
+	const uuid = "b831381d-6324-4d53-ad4f-8cda48b30811"
+	tests := []struct {
+		name     string
+		outbound string
+		check    func(node ProxyNode) bool
+	}{
+		{"sing-box hysteria2 port hopping",
+			`{"type": "hysteria2", "tag": "hy2", "server": "hy2.example.com", "server_port": 443, "server_ports": ["20000:30000", "40000:40100"], "password": "secret", "obfs": {"type": "salamander", "password": "obfs"}}`,
+			func(node ProxyNode) bool {
+				return node.Ports == "20000-30000,40000-40100" && node.Obfs == "salamander" && node.Network == ""
+			}},
+		{"sing-box shadowsocks plugin",
+			`{"type": "shadowsocks", "tag": "ss", "server": "ss.example.com", "server_port": 8388, "method": "aes-128-gcm", "password": "secret", "plugin": "obfs-local", "plugin_opts": "obfs=http;obfs-host=cdn.example.com"}`,
+			func(node ProxyNode) bool {
+				return node.Type == "ss" && node.Plugin == "obfs" && node.PluginOpts["host"] == "cdn.example.com" && node.TLS == nil
+			}},
+		{"sing-box vless reality IPv6",
+			`{"type": "vless", "tag": "reality", "server": "2001:db8::1", "server_port": 443, "uuid": "` + uuid + `", "flow": "xtls-rprx-vision", "tls": {"enabled": true, "server_name": "www.microsoft.com", "reality": {"enabled": true, "public_key": "pbk", "short_id": "ab"}}}`,
+			func(node ProxyNode) bool {
+				return node.Server == "2001:db8::1" && node.RealityOpts != nil && node.RealityOpts.ShortID == "ab" && node.Fingerprint == "chrome"
+			}},
+		{"sing-box vmess ws",
+			`{"type": "vmess", "tag": "ws", "server": "vmess.example.com", "server_port": 443, "uuid": "` + uuid + `", "tls": {"enabled": true}, "transport": {"type": "ws", "path": "/ws", "headers": {"Host": "cdn.example.com"}}}`,
+			func(node ProxyNode) bool {
+				return node.Cipher == "auto" && *node.TLS && transportSummary(&node) == "ws cdn.example.com /ws  "
+			}},
+		{"xray vless reality grpc multi",
+			`{"protocol": "vless", "tag": "xray", "settings": {"vnext": [{"address": "vless.example.com", "port": 443, "users": [{"id": "` + uuid + `", "flow": ""}]}]}, "streamSettings": {"network": "grpc", "security": "reality", "realitySettings": {"serverName": "www.microsoft.com", "publicKey": "pbk", "shortId": "cd", "spiderX": "/", "fingerprint": "firefox"}, "grpcSettings": {"serviceName": "svc", "multiMode": true}}}`,
+			func(node ProxyNode) bool {
+				return node.RealityOpts != nil && node.RealityOpts.SpiderX == "/" && node.Fingerprint == "firefox" && transportSummary(&node) == "grpc   svc multi"
+			}},
+		{"xray trojan IPv6",
+			`{"protocol": "trojan", "tag": "trojan", "settings": {"servers": [{"address": "2001:db8::2", "port": 443, "password": "secret"}]}, "streamSettings": {"network": "tcp", "security": "tls", "tlsSettings": {"serverName": "trojan.example.com", "allowInsecure": true}}}`,
+			func(node ProxyNode) bool {
+				return node.Server == "2001:db8::2" && node.SNI == "trojan.example.com" && node.SkipCertVerify && *node.TLS
+			}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 完整配置、出站数组与单个出站三种写法，直连等非代理出站被忽略
+			for _, content := range []string{
+				`{"log": {}, "outbounds": [{"type": "direct", "tag": "direct"}, ` + tt.outbound + `, {"protocol": "freedom"}]}`,
+				`[` + tt.outbound + `]`,
+				tt.outbound,
+			} {
+				nodes, err := ParseProxyLinks(content)
+				if err != nil {
+					t.Fatalf("ParseProxyLinks: %v", err)
+				}
+				if len(nodes) != 1 || !tt.check(nodes[0]) {
+					t.Errorf("nodes = %+v", nodes)
+				}
+			}
+		})
+	}
+
+	for _, content := range []string{
+		`{`,
+		`[]`,
+		`{}`,
+		`[1, 2]`,
+		`{"outbounds": "not a list"}`,
+		`{"outbounds": [{"type": "direct"}, {"type": "selector", "outbounds": ["a"]}]}`,
+		`{"type": "trojan", "server": "trojan.example.com", "password": "secret"}`,
+		`{"type": "trojan", "server": "trojan.example.com", "server_port": 443}`,
+		`{"type": "vless", "server": "vless.example.com", "server_port": 443}`,
+		`{"type": "trojan", "server": "trojan.example.com", "server_port": 443, "password": "secret", "transport": {"type": "quic"}}`,
+		`{"type": "wireguard", "server": "wg.example.com", "server_port": 51820}`,
+		`{"protocol": "vmess", "settings": {"vnext": []}}`,
+		`{"protocol": "trojan", "settings": {"servers": [{"address": "", "port": 443, "password": "secret"}]}}`,
+		`{"protocol": "vless", "settings": {"vnext": [{"address": "vless.example.com", "port": 443, "users": [{"id": "` + uuid + `"}]}]}, "streamSettings": {"security": "reality"}}`,
+		`{"type": "vless", "server": "vless.example.com", "server_port": 443, "uuid": "` + uuid + `", "tls": {"enabled": true, "reality": {"enabled": true}}}`,
+	} {
+		if nodes, err := ParseProxyLinks(content); err == nil {
+			t.Errorf("%q: nodes = %+v, want error", content, nodes)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+// backend/proxy_groups.go
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 代理组类型，各输出格式按自身语法渲染
+const (
+	groupTypeSelect      = "select"
+	groupTypeURLTest     = "url-test"
+	groupTypeFallback    = "fallback"
+	groupTypeLoadBalance = "load-balance"
+)
+
+// 负载均衡策略
+const (
+	strategyConsistentHashing = "consistent-hashing"
+	strategyRoundRobin        = "round-robin"
+	strategyStickySessions    = "sticky-sessions"
+)
+
+// ProxyGroup 与输出格式无关的代理组定义
+type ProxyGroup struct {
+	Name      string
+	Type      string   // select、url-test、fallback 或 load-balance
+	Proxies   []string // 节点名称、其他代理组名称或 DIRECT
+	Strategy  string   // 负载均衡策略，仅 load-balance 使用
+	URL       string   // 测速地址，select 以外的类型使用
+	Interval  int      // 测速间隔 (秒)
+	Tolerance int      // url-test 切换节点的延迟容差 (毫秒)
+}
+
+// ProxyGroupTemplate 用户自定义代理组
+// 成员由引用的代理组 (按填写顺序) 与筛选出的节点组成；只填写了引用而没有任何筛选条件时，不包含节点
+type ProxyGroupTemplate struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Strategy  string   `json:"strategy,omitempty"`  // load-balance 策略，默认 consistent-hashing
+	URL       string   `json:"url,omitempty"`       // 测速地址，默认与自动选择组相同
+	Interval  int      `json:"interval,omitempty"`  // 测速间隔 (秒)
+	Tolerance int      `json:"tolerance,omitempty"` // 延迟容差 (毫秒)
+	Include   string   `json:"include,omitempty"`   // 节点名称需匹配的正则表达式
+	Exclude   string   `json:"exclude,omitempty"`   // 节点名称不能匹配的正则表达式
+	Protocols []string `json:"protocols,omitempty"` // 节点协议类型白名单，如 vmess、ss
+	Groups    []string `json:"groups,omitempty"`    // 引用的代理组名称，可以是内置组、地区组、其他自定义组或 DIRECT
+}
+
+// hasNodeFilter 判断模板是否设置了节点筛选条件
+func (t ProxyGroupTemplate) hasNodeFilter() bool {
+	return t.Include != "" || t.Exclude != "" || len(t.Protocols) > 0
+}
+
+// buildProxyGroups 根据节点与生成选项构建代理组
+// 默认包含节点选择、自动选择与全球直连三个组；启用地区分组时，额外为每个地区生成 url-test 组并加入节点选择；
+// 用户自定义的代理组排在最后，不会被加入节点选择，因此可以反过来引用节点选择等内置组
+func buildProxyGroups(nodes []ProxyNode, config GenerateRequest) ([]ProxyGroup, error) {
+	proxyNames := proxyNamesOf(nodes)
+
+	nodeSelect := ProxyGroup{
+		Name:    groupNodeSelect,
+		Type:    groupTypeSelect,
+		Proxies: []string{groupAutoSelect},
+	}
+	groups := []ProxyGroup{
+		newURLTestGroup(groupAutoSelect, proxyNames),
+	}
+
+	if config.RegionGroups {
+		for _, group := range buildRegionGroups(proxyNames) {
+			nodeSelect.Proxies = append(nodeSelect.Proxies, group.Name)
+			groups = append(groups, group)
+		}
+	}
+
+	nodeSelect.Proxies = append(nodeSelect.Proxies, groupDirect)
+	nodeSelect.Proxies = append(nodeSelect.Proxies, proxyNames...)
+
+	groups = append([]ProxyGroup{nodeSelect}, groups...)
+	groups = append(groups, ProxyGroup{
+		Name:    groupDirect,
+		Type:    groupTypeSelect,
+		Proxies: []string{"DIRECT", groupNodeSelect},
+	})
+
+	customGroups, err := buildCustomGroups(nodes, groups, config)
+	if err != nil {
+		return nil, err
+	}
+	groups = append(groups, customGroups...)
+	if err := checkNodeNameConflicts(nodes, groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// reservedGroupNames 返回按生成选项可能输出的代理组名称与内置策略，节点不能使用这些名称
+func reservedGroupNames(config GenerateRequest) []string {
+	names := []string{groupNodeSelect, groupAutoSelect, groupDirect}
+	for policy := range builtinPolicies {
+		names = append(names, policy)
+	}
+	names = append(names, ruleTemplateGroupNames...)
+	if config.RegionGroups {
+		for _, region := range regions {
+			names = append(names, region.GroupName())
+		}
+	}
+	for _, template := range config.ProxyGroups {
+		names = append(names, template.Name)
+	}
+	return names
+}
+
+// checkNodeNameConflicts 检查节点是否与代理组重名，客户端无法区分同名的节点与代理组
+func checkNodeNameConflicts(nodes []ProxyNode, groups []ProxyGroup) error {
+	groupNames := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		groupNames[group.Name] = true
+	}
+	for _, node := range nodes {
+		if groupNames[node.Name] {
+			return fmt.Errorf("节点 %s 与代理组重名", node.Name)
+		}
+	}
+	return nil
+}
+
+// proxyNamesOf 返回节点名称列表
+func proxyNamesOf(nodes []ProxyNode) []string {
+	names := make([]string, len(nodes))
+	for i, node := range nodes {
+		names[i] = node.Name
+	}
+	return names
+}
+
+// newURLTestGroup 创建使用默认测速参数的 url-test 组
+func newURLTestGroup(name string, proxies []string) ProxyGroup {
+	return ProxyGroup{
+		Name:      name,
+		Type:      groupTypeURLTest,
+		Proxies:   proxies,
+		URL:       proxyTestURL,
+		Interval:  proxyTestInterval,
+		Tolerance: proxyTestTolerance,
+	}
+}
+
+// buildRegionGroups 按节点名称识别地区，为包含节点的地区生成 url-test 组，无法识别的节点不参与分组
+func buildRegionGroups(proxyNames []string) []ProxyGroup {
+	regionNodes := make(map[*Region][]string)
+	for _, name := range proxyNames {
+		if region := classifyRegion(name); region != nil {
+			regionNodes[region] = append(regionNodes[region], name)
+		}
+	}
+
+	var groups []ProxyGroup
+	for _, region := range regions {
+		if names := regionNodes[region]; len(names) > 0 {
+			groups = append(groups, newURLTestGroup(region.GroupName(), names))
+		}
+	}
+	return groups
+}
+
+// buildCustomGroups 按用户模板生成代理组，builtinGroups 为已生成的内置组与地区组
+func buildCustomGroups(nodes []ProxyNode, builtinGroups []ProxyGroup, config GenerateRequest) ([]ProxyGroup, error) {
+	if len(config.ProxyGroups) == 0 {
+		return nil, nil
+	}
+	if err := validateProxyGroupTemplates(config.ProxyGroups, config.RegionGroups); err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool)
+	for _, group := range builtinGroups {
+		existing[group.Name] = true
+	}
+	for _, template := range config.ProxyGroups {
+		existing[template.Name] = true
+	}
+
+	groups := make([]ProxyGroup, 0, len(config.ProxyGroups))
+	for _, template := range config.ProxyGroups {
+		group := ProxyGroup{
+			Name:      template.Name,
+			Type:      template.Type,
+			Strategy:  template.Strategy,
+			URL:       template.URL,
+			Interval:  template.Interval,
+			Tolerance: template.Tolerance,
+		}
+		if group.Type != groupTypeSelect {
+			if group.URL == "" {
+				group.URL = proxyTestURL
+			}
+			if group.Interval == 0 {
+				group.Interval = proxyTestInterval
+			}
+		}
+		if group.Type == groupTypeURLTest && group.Tolerance == 0 {
+			group.Tolerance = proxyTestTolerance
+		}
+		if group.Type == groupTypeLoadBalance && group.Strategy == "" {
+			group.Strategy = strategyConsistentHashing
+		}
+
+		// 地区组只在存在对应节点时生成，引用不存在的地区组时直接忽略
+		for _, ref := range template.Groups {
+			if ref == "DIRECT" || existing[ref] {
+				group.Proxies = append(group.Proxies, ref)
+			}
+		}
+
+		if template.hasNodeFilter() || len(template.Groups) == 0 {
+			// 正则表达式已在校验时检查过
+			include, _ := compileGroupFilter(template.Include)
+			exclude, _ := compileGroupFilter(template.Exclude)
+			for _, node := range nodes {
+				if matchGroupFilter(node, template, include, exclude) {
+					group.Proxies = append(group.Proxies, node.Name)
+				}
+			}
+		}
+
+		// 客户端不接受空代理组，没有任何成员时使用直连占位
+		if len(group.Proxies) == 0 {
+			group.Proxies = []string{"DIRECT"}
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// validateProxyGroupTemplates 校验自定义代理组的名称、类型、筛选条件与引用关系，并拒绝循环引用
+func validateProxyGroupTemplates(templates []ProxyGroupTemplate, regionGroups bool) error {
+	reserved := map[string]bool{
+		"DIRECT":        true,
+		"REJECT":        true,
+		groupNodeSelect: true,
+		groupAutoSelect: true,
+		groupDirect:     true,
+	}
+	// 可引用的内置组；地区组是否生成取决于节点，启用地区分组时全部视为可引用
+	referable := map[string]bool{
+		"DIRECT":        true,
+		groupNodeSelect: true,
+		groupAutoSelect: true,
+		groupDirect:     true,
+	}
+	for _, name := range ruleTemplateGroupNames {
+		reserved[name] = true
+	}
+	for _, region := range regions {
+		reserved[region.GroupName()] = true
+		if regionGroups {
+			referable[region.GroupName()] = true
+		}
+	}
+
+	custom := make(map[string]ProxyGroupTemplate)
+	for _, template := range templates {
+		name := template.Name
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("代理组名称不能为空")
+		}
+		if name != strings.TrimSpace(name) || strings.ContainsAny(name, ",=\r\n") {
+			return fmt.Errorf("代理组 %s: 名称不能包含首尾空白、逗号或等号", name)
+		}
+		if reserved[name] {
+			return fmt.Errorf("代理组 %s: 名称与内置代理组重复", name)
+		}
+		if _, ok := custom[name]; ok {
+			return fmt.Errorf("代理组 %s: 名称重复", name)
+		}
+
+		switch template.Type {
+		case groupTypeSelect, groupTypeURLTest, groupTypeFallback, groupTypeLoadBalance:
+		default:
+			return fmt.Errorf("代理组 %s: 不支持的类型 %s", name, template.Type)
+		}
+		switch template.Strategy {
+		case "":
+		case strategyConsistentHashing, strategyRoundRobin, strategyStickySessions:
+			if template.Type != groupTypeLoadBalance {
+				return fmt.Errorf("代理组 %s: 只有 load-balance 类型可以设置负载均衡策略", name)
+			}
+		default:
+			return fmt.Errorf("代理组 %s: 不支持的负载均衡策略 %s", name, template.Strategy)
+		}
+		if template.Interval < 0 || template.Tolerance < 0 {
+			return fmt.Errorf("代理组 %s: 测速间隔与容差不能为负数", name)
+		}
+		if _, err := compileGroupFilter(template.Include); err != nil {
+			return fmt.Errorf("代理组 %s: include 正则表达式无效: %v", name, err)
+		}
+		if _, err := compileGroupFilter(template.Exclude); err != nil {
+			return fmt.Errorf("代理组 %s: exclude 正则表达式无效: %v", name, err)
+		}
+		for _, protocol := range template.Protocols {
+			if !isSupportedProtocol(protocol) {
+				return fmt.Errorf("代理组 %s: 不支持的协议类型 %s", name, protocol)
+			}
+		}
+		custom[name] = template
+	}
+
+	for _, template := range templates {
+		for _, ref := range template.Groups {
+			if ref == template.Name {
+				return fmt.Errorf("代理组 %s: 不能引用自身", template.Name)
+			}
+			if _, ok := custom[ref]; !ok && !referable[ref] {
+				return fmt.Errorf("代理组 %s: 引用了不存在的代理组 %s", template.Name, ref)
+			}
+		}
+	}
+
+	return checkGroupCycles(templates, custom)
+}
+
+// checkGroupCycles 深度优先遍历自定义代理组之间的引用，发现环时返回包含完整路径的错误
+// 内置组不会引用自定义组，因此只需检查自定义组之间的引用
+func checkGroupCycles(templates []ProxyGroupTemplate, custom map[string]ProxyGroupTemplate) error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			start := 0
+			for path[start] != name {
+				start++
+			}
+			return fmt.Errorf("代理组存在循环引用: %s", strings.Join(append(path[start:], name), " -> "))
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, ref := range custom[name].Groups {
+			if _, ok := custom[ref]; ok {
+				if err := visit(ref); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, template := range templates {
+		if err := visit(template.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileGroupFilter 编译节点筛选正则表达式，空表达式返回 nil
+func compileGroupFilter(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+// matchGroupFilter 判断节点是否满足自定义代理组的筛选条件
+func matchGroupFilter(node ProxyNode, template ProxyGroupTemplate, include, exclude *regexp.Regexp) bool {
+	if include != nil && !include.MatchString(node.Name) {
+		return false
+	}
+	if exclude != nil && exclude.MatchString(node.Name) {
+		return false
+	}
+	if len(template.Protocols) == 0 {
+		return true
+	}
+	for _, protocol := range template.Protocols {
+		if strings.EqualFold(protocol, node.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSupportedProtocol 判断是否为解析器支持的节点协议类型
+func isSupportedProtocol(protocol string) bool {
+	switch strings.ToLower(protocol) {
+	case "vmess", "vless", "ss", "trojan", "hysteria2", "tuic":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,93 @@
+// backend/proxy_groups_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateProxyGroupTemplatesReferences(t *testing.T) {
+	tests := []struct {
+		name      string
+		templates []ProxyGroupTemplate
+		errMsg    string // 为空时应校验通过
+	}{
+		{"chain", []ProxyGroupTemplate{
+			{Name: "A", Type: groupTypeSelect, Groups: []string{"B"}},
+			{Name: "B", Type: groupTypeSelect, Groups: []string{"C", groupNodeSelect}},
+			{Name: "C", Type: groupTypeURLTest, Include: "HK"},
+		}, ""},
+		{"self reference", []ProxyGroupTemplate{
+			{Name: "A", Type: groupTypeSelect, Groups: []string{"A"}},
+		}, "不能引用自身"},
+		{"two groups", []ProxyGroupTemplate{
+			{Name: "A", Type: groupTypeSelect, Groups: []string{"B"}},
+			{Name: "B", Type: groupTypeSelect, Groups: []string{"A"}},
+		}, "循环引用: A -> B -> A"},
+		{"three groups", []ProxyGroupTemplate{
+			{Name: "X", Type: groupTypeSelect, Groups: []string{"A"}},
+			{Name: "A", Type: groupTypeSelect, Groups: []string{"B"}},
+			{Name: "B", Type: groupTypeSelect, Groups: []string{"C"}},
+			{Name: "C", Type: groupTypeSelect, Groups: []string{"A"}},
+		}, "循环引用: A -> B -> C -> A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateProxyGroupTemplates(tt.templates, false)
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("err = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("err = %v, want error containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestNodeGroupNameCollisions(t *testing.T) {
+	config := GenerateRequest{
+		RegionGroups: true,
+		ProxyGroups:  []ProxyGroupTemplate{{Name: "Custom", Type: groupTypeSelect}},
+	}
+	names := []string{groupNodeSelect, "🇭🇰 香港", groupStreaming, "Custom", "DIRECT", "Custom 2", "HK 01"}
+	var nodes []ProxyNode
+	for _, name := range names {
+		nodes = append(nodes, ProxyNode{Name: name, Type: "trojan", Server: "hk.example.com", Port: 443, Password: "secret"})
+	}
+
+	// 未处理时生成代理组会报告重名
+	if _, err := buildProxyGroups(nodes, config); err == nil || !strings.Contains(err.Error(), "重名") {
+		t.Errorf("buildProxyGroups err = %v, want name conflict", err)
+	}
+
+	nodes, renamed := DisambiguateNames(nodes, reservedGroupNames(config))
+	want := []string{groupNodeSelect + " 2", "🇭🇰 香港 2", groupStreaming + " 2", "Custom 3", "DIRECT 2", "Custom 2", "HK 01"}
+	if got := proxyNamesOf(nodes); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("names = %q, want %q", got, want)
+	}
+	if len(renamed) != 5 {
+		t.Errorf("renamed = %+v, want 5 nodes", renamed)
+	}
+
+	ruleTemplate, err := findRuleTemplate("full")
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups, err := buildClashProxyGroups(nodes, config, ruleTemplate)
+	if err != nil {
+		t.Fatalf("buildClashProxyGroups: %v", err)
+	}
+	groupNames := make(map[string]bool)
+	for _, group := range groups {
+		groupNames[group.Name] = true
+	}
+	for _, node := range nodes {
+		if groupNames[node.Name] {
+			t.Errorf("node %s still collides with a group", node.Name)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+// backend/proxy_probe.go
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// proxyTestTimeout 通过节点访问测试地址的超时时间，包含协议握手
+const proxyTestTimeout = 10 * time.Second
+
+// errProxyAuth 节点建立连接后未返回任何数据就关闭连接、响应无法解密或响应来自回落站点
+// 服务端在认证失败时不会明确告知，只会断开或转发到回落站点，因此只能据此判断
+var errProxyAuth = errors.New("节点拒绝了请求，请检查 UUID/密码与加密方式")
+
+// ProxyTest 通过节点访问测试地址的结果
+type ProxyTest struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Latency    int    `json:"latency"`           // 从开始连接节点到收到测试地址响应头的耗时毫秒
+	Skipped    string `json:"skipped,omitempty"` // 节点不支持协议测试的原因，此时只做连接检测
+}
+
+// proxyTunnelDialers 支持协议测试的协议
+var proxyTunnelDialers = map[string]func(conn net.Conn, node ProxyNode, target proxyTarget) (net.Conn, error){
+	"vmess":  newVMessConn,
+	"vless":  newVLESSConn,
+	"trojan": newTrojanConn,
+	"ss":     newShadowsocksConn,
+}
+
+// validateTestURL 校验测试地址，协议测试依赖连通性检测
+func validateTestURL(testURL string, checkNodes bool) error {
+	if testURL == "" {
+		return nil
+	}
+	if !checkNodes {
+		return fmt.Errorf("通过节点访问测试地址需要开启节点连通性检测")
+	}
+	target, err := url.Parse(testURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return fmt.Errorf("测试地址 %s 无效，需要以 http:// 或 https:// 开头", testURL)
+	}
+	return nil
+}
+
+// proxyTestUnsupported 返回节点无法进行协议测试的原因，可以测试时返回空字符串
+func proxyTestUnsupported(node ProxyNode) string {
+	if proxyTunnelDialers[node.Type] == nil {
+		return fmt.Sprintf("暂不支持 %s 协议", node.Type)
+	}
+	if node.RealityOpts != nil {
+		return "暂不支持 Reality"
+	}
+	if node.Flow != "" {
+		return fmt.Sprintf("暂不支持流控 %s", node.Flow)
+	}
+	if node.Type == "ss" {
+		if node.Plugin != "" {
+			return fmt.Sprintf("暂不支持插件 %s", node.Plugin)
+		}
+		if _, ok := shadowsocksCiphers[strings.ToLower(node.Cipher)]; !ok {
+			return fmt.Sprintf("暂不支持加密方式 %s", node.Cipher)
+		}
+	}
+	switch node.Network {
+	case "", "tcp", "ws":
+	default:
+		return fmt.Sprintf("暂不支持 %s 传输", node.Network)
+	}
+	return ""
+}
+
+// fetchThroughProxy 在已连接 (及完成 TLS 握手) 的节点连接上按节点协议请求测试地址，返回 HTTP 状态码
+func fetchThroughProxy(conn net.Conn, node ProxyNode, testURL string) (int, error) {
+	target, err := url.Parse(testURL)
+	if err != nil {
+		return 0, err
+	}
+	port := target.Port()
+	if port == "" {
+		port = "80"
+		if target.Scheme == "https" {
+			port = "443"
+		}
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		return 0, fmt.Errorf("测试地址端口 %s 无效", port)
+	}
+
+	conn.SetDeadline(time.Now().Add(proxyTestTimeout))
+	if node.Network == "ws" {
+		if conn, err = dialWebSocket(conn, node); err != nil {
+			return 0, err
+		}
+	}
+	tunnel, err := proxyTunnelDialers[node.Type](conn, node, proxyTarget{host: target.Hostname(), port: portNumber})
+	if err != nil {
+		return 0, err
+	}
+	tunnel = &proxyResponseConn{Conn: tunnel}
+	if target.Scheme == "https" {
+		tunnel = tls.Client(tunnel, &tls.Config{ServerName: target.Hostname(), RootCAs: tlsCheckRootCAs})
+	}
+
+	req, err := http.NewRequest(http.MethodGet, testURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Close = true
+	req.Header.Set("User-Agent", "ClashLink")
+	if err := req.Write(tunnel); err != nil {
+		return 0, classifyTunnelError(err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(tunnel), req)
+	if err != nil {
+		return 0, classifyTunnelError(err)
+	}
+	resp.Body.Close()
+	// Trojan 认证失败时连接被转发到回落站点，回落站点无法解析以密码哈希开头的请求，只能返回 400。
+	// https 测试地址的回落响应已在 TLS 握手时按收到明文识别，这里的 400 来自测试地址本身；
+	// http 测试地址无法区分响应来源，只能据此推断
+	if node.Type == "trojan" && target.Scheme == "http" && resp.StatusCode == http.StatusBadRequest {
+		return 0, fmt.Errorf("%w: 请求被转发到了回落站点", errProxyAuth)
+	}
+	return resp.StatusCode, nil
+}
+
+// classifyTunnelError 期望测试地址的 TLS 握手却收到了明文，说明数据来自回落站点而不是测试地址
+func classifyTunnelError(err error) error {
+	var recordErr tls.RecordHeaderError
+	if errors.As(err, &recordErr) {
+		return fmt.Errorf("%w: 测试地址的 TLS 握手收到了非 TLS 数据", errProxyAuth)
+	}
+	return err
+}
+
+// proxyResponseConn 在节点未返回任何数据时把连接关闭识别为认证失败
+type proxyResponseConn struct {
+	net.Conn
+	received bool
+}
+
+func (c *proxyResponseConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.received = true
+	}
+	if err != nil && !c.received && (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)) {
+		return n, errProxyAuth
+	}
+	return n, err
+}
+
+// dialWebSocket 在连接上完成 WebSocket 握手，返回以二进制帧收发数据的连接
+func dialWebSocket(conn net.Conn, node ProxyNode) (net.Conn, error) {
+	path, host := "/", node.Server
+	if node.SNI != "" {
+		host = node.SNI
+	}
+	var headers map[string]string
+	if node.WSOpts != nil {
+		if node.WSOpts.Path != "" {
+			path = node.WSOpts.Path
+		}
+		headers = node.WSOpts.Headers
+	}
+	if headers["Host"] != "" {
+		host = headers["Host"]
+	}
+
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	var request strings.Builder
+	fmt.Fprintf(&request, "GET %s HTTP/1.1\r\nHost: %s\r\n", path, host)
+	fmt.Fprintf(&request, "Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n", base64.StdEncoding.EncodeToString(key))
+	for name, value := range headers {
+		if name != "Host" {
+			fmt.Fprintf(&request, "%s: %s\r\n", name, value)
+		}
+	}
+	request.WriteString("\r\n")
+	if _, err := io.WriteString(conn, request.String()); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		return nil, fmt.Errorf("WebSocket 握手失败: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("WebSocket 握手失败: %s", resp.Status)
+	}
+	return &webSocketConn{Conn: conn, reader: reader}, nil
+}
+
+// webSocketConn 客户端 WebSocket 连接，写入的数据以掩码二进制帧发送
+type webSocketConn struct {
+	net.Conn
+	reader    *bufio.Reader
+	remaining uint64 // 当前帧未读取的载荷长度
+}
+
+func (c *webSocketConn) Write(b []byte) (int, error) {
+	frame := []byte{0x82}
+	switch length := len(b); {
+	case length < 126:
+		frame = append(frame, 0x80|byte(length))
+	case length <= 0xFFFF:
+		frame = binary.BigEndian.AppendUint16(append(frame, 0x80|126), uint16(length))
+	default:
+		frame = binary.BigEndian.AppendUint64(append(frame, 0x80|127), uint64(length))
+	}
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return 0, err
+	}
+	frame = append(frame, mask...)
+	for i, value := range b {
+		frame = append(frame, value^mask[i%4])
+	}
+	if _, err := c.Conn.Write(frame); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *webSocketConn) Read(b []byte) (int, error) {
+	for c.remaining == 0 {
+		var head [2]byte
+		if _, err := io.ReadFull(c.reader, head[:]); err != nil {
+			return 0, err
+		}
+		length := uint64(head[1] & 0x7F)
+		switch length {
+		case 126:
+			var extended [2]byte
+			if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+				return 0, err
+			}
+			length = uint64(binary.BigEndian.Uint16(extended[:]))
+		case 127:
+			var extended [8]byte
+			if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+				return 0, err
+			}
+			length = binary.BigEndian.Uint64(extended[:])
+		}
+		if head[1]&0x80 != 0 {
+			return 0, errors.New("WebSocket 服务端发送了带掩码的帧")
+		}
+
+		switch head[0] & 0x0F {
+		case 0x8: // 关闭帧
+			return 0, io.EOF
+		case 0x9, 0xA: // ping/pong 不含数据
+			if _, err := io.CopyN(io.Discard, c.reader, int64(length)); err != nil {
+				return 0, err
+			}
+		default:
+			c.remaining = length
+		}
+	}
+
+	if uint64(len(b)) > c.remaining {
+		b = b[:c.remaining]
+	}
+	n, err := c.reader.Read(b)
+	c.remaining -= uint64(n)
+	return n, err
+}
@@ -0,0 +1,592 @@
+// backend/proxy_probe_test.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	testProxyUUID     = "b831381d-6324-4d53-ad4f-8cda48b30811"
+	testProxyPassword = "secret"
+	testWebSocketPath = "/ws"
+)
+
+// proxyHandler 测试节点服务端处理一个已完成 TLS/WebSocket 握手的连接
+// fallback 为回落站点地址，认证失败时连同已读取的数据一起转发过去
+type proxyHandler func(conn net.Conn, fallback string) error
+
+// startProxyServer 启动测试节点服务端，返回监听地址
+func startProxyServer(t *testing.T, handler proxyHandler, tlsConfig *tls.Config, webSocket bool, fallback string) (string, int) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(5 * time.Second))
+				if webSocket {
+					if conn, err = acceptWebSocket(conn); err != nil {
+						return
+					}
+				}
+				handler(conn, fallback)
+			}()
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+// readTestAddress 读取各协议的目标地址，portFirst 表示端口位于地址之前 (VLESS/VMess)
+func readTestAddress(r io.Reader, ipv4, domain, ipv6 byte, portFirst bool) (string, error) {
+	var port [2]byte
+	if portFirst {
+		if _, err := io.ReadFull(r, port[:]); err != nil {
+			return "", err
+		}
+	}
+	var addressType [1]byte
+	if _, err := io.ReadFull(r, addressType[:]); err != nil {
+		return "", err
+	}
+	var host []byte
+	switch addressType[0] {
+	case ipv4:
+		host = make([]byte, 4)
+	case ipv6:
+		host = make([]byte, 16)
+	case domain:
+		var length [1]byte
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			return "", err
+		}
+		host = make([]byte, length[0])
+	default:
+		return "", fmt.Errorf("unknown address type %d", addressType[0])
+	}
+	if _, err := io.ReadFull(r, host); err != nil {
+		return "", err
+	}
+	if !portFirst {
+		if _, err := io.ReadFull(r, port[:]); err != nil {
+			return "", err
+		}
+	}
+	hostname := string(host)
+	if addressType[0] != domain {
+		hostname = net.IP(host).String()
+	}
+	return net.JoinHostPort(hostname, strconv.Itoa(int(binary.BigEndian.Uint16(port[:])))), nil
+}
+
+// relay 连接目标地址并双向转发，目标关闭连接 (测试请求带 Connection: close) 后返回
+func relay(r io.Reader, w io.Writer, address string) error {
+	target, err := net.Dial("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+	go io.Copy(target, r)
+	_, err = io.Copy(w, target)
+	return err
+}
+
+// forwardToFallback 像真实服务端一样把认证失败的连接 (含已读取的数据) 转发到回落站点
+func forwardToFallback(conn net.Conn, consumed []byte, fallback string) error {
+	return relay(io.MultiReader(bytes.NewReader(consumed), conn), conn, fallback)
+}
+
+// trojanHandler Trojan 服务端，密码错误时转发到回落站点
+func trojanHandler(conn net.Conn, fallback string) error {
+	head := make([]byte, 58)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return err
+	}
+	sum := sha256.Sum224([]byte(testProxyPassword))
+	if string(head[:56]) != hex.EncodeToString(sum[:]) {
+		return forwardToFallback(conn, head, fallback)
+	}
+	reader := bufio.NewReader(conn)
+	if command, err := reader.ReadByte(); err != nil || command != 0x01 {
+		return fmt.Errorf("unexpected command %d: %v", command, err)
+	}
+	address, err := readTestAddress(reader, 0x01, 0x03, 0x04, false)
+	if err != nil {
+		return err
+	}
+	if _, err := reader.Discard(2); err != nil {
+		return err
+	}
+	return relay(reader, conn, address)
+}
+
+// vlessHandler VLESS 服务端，UUID 错误时转发到回落站点
+func vlessHandler(conn net.Conn, fallback string) error {
+	head := make([]byte, 18)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return err
+	}
+	id, _ := parseProxyUUID(testProxyUUID)
+	if head[0] != 0x00 || !bytes.Equal(head[1:17], id[:]) {
+		return forwardToFallback(conn, head, fallback)
+	}
+	reader := bufio.NewReader(conn)
+	if _, err := reader.Discard(int(head[17])); err != nil {
+		return err
+	}
+	if command, err := reader.ReadByte(); err != nil || command != 0x01 {
+		return fmt.Errorf("unexpected command %d: %v", command, err)
+	}
+	address, err := readTestAddress(reader, 0x01, 0x02, 0x03, true)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write([]byte{0x00, 0x00}); err != nil {
+		return err
+	}
+	return relay(reader, conn, address)
+}
+
+// ssServerStream Shadowsocks AEAD 服务端数据流
+type ssServerStream struct {
+	conn    net.Conn
+	method  string
+	key     []byte
+	reader  cipher.AEAD
+	rNonce  []byte
+	pending []byte
+	writer  cipher.AEAD
+	wNonce  []byte
+}
+
+func (s *ssServerStream) aead(salt []byte) (cipher.AEAD, error) {
+	ssCipher := shadowsocksCiphers[s.method]
+	subkey := make([]byte, ssCipher.keySize)
+	if _, err := io.ReadFull(hkdf.New(sha1.New, s.key, salt, []byte("ss-subkey")), subkey); err != nil {
+		return nil, err
+	}
+	return ssCipher.newAEAD(subkey)
+}
+
+func (s *ssServerStream) Read(b []byte) (int, error) {
+	for len(s.pending) == 0 {
+		if s.reader == nil {
+			salt := make([]byte, len(s.key))
+			if _, err := io.ReadFull(s.conn, salt); err != nil {
+				return 0, err
+			}
+			aead, err := s.aead(salt)
+			if err != nil {
+				return 0, err
+			}
+			s.reader, s.rNonce = aead, make([]byte, aead.NonceSize())
+		}
+		sealedLength := make([]byte, 2+s.reader.Overhead())
+		if _, err := io.ReadFull(s.conn, sealedLength); err != nil {
+			return 0, err
+		}
+		length, err := s.reader.Open(nil, s.rNonce, sealedLength, nil)
+		if err != nil {
+			return 0, err
+		}
+		increaseNonce(s.rNonce)
+		sealed := make([]byte, int(binary.BigEndian.Uint16(length))+s.reader.Overhead())
+		if _, err := io.ReadFull(s.conn, sealed); err != nil {
+			return 0, err
+		}
+		if s.pending, err = s.reader.Open(nil, s.rNonce, sealed, nil); err != nil {
+			return 0, err
+		}
+		increaseNonce(s.rNonce)
+	}
+	n := copy(b, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func (s *ssServerStream) Write(b []byte) (int, error) {
+	var out []byte
+	if s.writer == nil {
+		salt := make([]byte, len(s.key))
+		rand.Read(salt)
+		aead, err := s.aead(salt)
+		if err != nil {
+			return 0, err
+		}
+		s.writer, s.wNonce, out = aead, make([]byte, aead.NonceSize()), salt
+	}
+	out = s.writer.Seal(out, s.wNonce, binary.BigEndian.AppendUint16(nil, uint16(len(b))), nil)
+	increaseNonce(s.wNonce)
+	out = s.writer.Seal(out, s.wNonce, b, nil)
+	increaseNonce(s.wNonce)
+	if _, err := s.conn.Write(out); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// shadowsocksHandler 返回使用指定加密方式的 Shadowsocks 服务端，解密失败时与真实服务端一样直接断开
+func shadowsocksHandler(method string) proxyHandler {
+	return func(conn net.Conn, fallback string) error {
+		stream := &ssServerStream{conn: conn, method: method, key: evpBytesToKey(testProxyPassword, shadowsocksCiphers[method].keySize)}
+		address, err := readTestAddress(stream, 0x01, 0x03, 0x04, false)
+		if err != nil {
+			return err
+		}
+		return relay(stream, stream, address)
+	}
+}
+
+// vmessHandler VMess AEAD 服务端，认证失败时直接断开
+func vmessHandler(conn net.Conn, fallback string) error {
+	id, _ := parseProxyUUID(testProxyUUID)
+	cmdKey := md5.Sum(append(id[:], vmessCmdKeySalt...))
+
+	authID := make([]byte, 16)
+	if _, err := io.ReadFull(conn, authID); err != nil {
+		return err
+	}
+	block, _ := aes.NewCipher(vmessKDF(cmdKey[:], vmessAuthIDKey)[:16])
+	plainAuthID := make([]byte, 16)
+	block.Decrypt(plainAuthID, authID)
+	if crc32.ChecksumIEEE(plainAuthID[:12]) != binary.BigEndian.Uint32(plainAuthID[12:]) {
+		return errors.New("invalid auth id")
+	}
+
+	sealedLength := make([]byte, 18)
+	connectionNonce := make([]byte, 8)
+	if _, err := io.ReadFull(conn, sealedLength); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(conn, connectionNonce); err != nil {
+		return err
+	}
+	lengthAEAD, lengthNonce, _ := openVMessAEAD(cmdKey[:], cmdKey[:], vmessHeaderLengthKey, vmessHeaderLengthNonce, string(authID), string(connectionNonce))
+	length, err := lengthAEAD.Open(nil, lengthNonce, sealedLength, authID)
+	if err != nil {
+		return err
+	}
+	sealedHeader := make([]byte, int(binary.BigEndian.Uint16(length))+16)
+	if _, err := io.ReadFull(conn, sealedHeader); err != nil {
+		return err
+	}
+	headerAEAD, headerNonce, _ := openVMessAEAD(cmdKey[:], cmdKey[:], vmessHeaderKey, vmessHeaderNonce, string(authID), string(connectionNonce))
+	header, err := headerAEAD.Open(nil, headerNonce, sealedHeader, authID)
+	if err != nil {
+		return err
+	}
+
+	// 版本 1、IV 16、密钥 16、响应认证 1、选项 1、填充长度与加密方式 1、保留 1、命令 1
+	requestIV, requestKey, responseAuth := header[1:17], header[17:33], header[33]
+	if header[35]&0x0F != vmessSecurityAES128GCM || header[37] != 0x01 {
+		return fmt.Errorf("unexpected security %d or command %d", header[35]&0x0F, header[37])
+	}
+	address, err := readTestAddress(bytes.NewReader(header[38:]), 0x01, 0x02, 0x03, true)
+	if err != nil {
+		return err
+	}
+
+	requestAEAD, _ := newAESGCM(requestKey)
+	responseKey := sha256.Sum256(requestKey)
+	responseIV := sha256.Sum256(requestIV)
+	responseAEAD, _ := newAESGCM(responseKey[:16])
+
+	// 请求体分块解密后写入管道
+	body, bodyWriter := io.Pipe()
+	go func() {
+		for count := uint16(0); ; count++ {
+			var size [2]byte
+			if _, err := io.ReadFull(conn, size[:]); err != nil {
+				bodyWriter.CloseWithError(err)
+				return
+			}
+			sealed := make([]byte, binary.BigEndian.Uint16(size[:]))
+			if _, err := io.ReadFull(conn, sealed); err != nil {
+				bodyWriter.CloseWithError(err)
+				return
+			}
+			chunk, err := requestAEAD.Open(nil, vmessChunkNonce(requestIV, count), sealed, nil)
+			if err != nil {
+				bodyWriter.CloseWithError(err)
+				return
+			}
+			bodyWriter.Write(chunk)
+		}
+	}()
+
+	// 响应头: 响应认证、选项、命令、命令长度
+	respLengthAEAD, respLengthNonce, _ := openVMessAEAD(responseKey[:16], responseIV[:16], vmessRespLengthKey, vmessRespLengthNonce)
+	respHeaderAEAD, respHeaderNonce, _ := openVMessAEAD(responseKey[:16], responseIV[:16], vmessRespHeaderKey, vmessRespHeaderNonce)
+	respHeader := []byte{responseAuth, 0x00, 0x00, 0x00}
+	out := respLengthAEAD.Seal(nil, respLengthNonce, binary.BigEndian.AppendUint16(nil, uint16(len(respHeader))), nil)
+	out = respHeaderAEAD.Seal(out, respHeaderNonce, respHeader, nil)
+	if _, err := conn.Write(out); err != nil {
+		return err
+	}
+
+	writer := &vmessServerWriter{conn: conn, aead: responseAEAD, iv: responseIV[:16]}
+	if err := relay(body, writer, address); err != nil {
+		return err
+	}
+	_, err = writer.Write(nil) // 空分块表示数据结束
+	return err
+}
+
+// vmessServerWriter 以 aes-128-gcm 分块加密写入响应数据
+type vmessServerWriter struct {
+	conn  net.Conn
+	aead  cipher.AEAD
+	iv    []byte
+	count uint16
+}
+
+func (w *vmessServerWriter) Write(b []byte) (int, error) {
+	out := binary.BigEndian.AppendUint16(nil, uint16(len(b)+w.aead.Overhead()))
+	out = w.aead.Seal(out, vmessChunkNonce(w.iv, w.count), b, nil)
+	w.count++
+	if _, err := w.conn.Write(out); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// acceptWebSocket 完成服务端 WebSocket 握手，路径不是 testWebSocketPath 时返回 404
+func acceptWebSocket(conn net.Conn) (net.Conn, error) {
+	reader := bufio.NewReader(conn)
+	req, err := http.ReadRequest(reader)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL.Path != testWebSocketPath || !strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		io.WriteString(conn, "HTTP/1.1 404 Not Found\r\nContent-Length: 0\r\n\r\n")
+		return nil, errors.New("unexpected WebSocket request")
+	}
+	accept := sha1.Sum([]byte(req.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	response := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(accept[:]) + "\r\n\r\n"
+	// 握手后先发送一个 ping，客户端需要跳过控制帧
+	if _, err := io.WriteString(conn, response+"\x89\x00"); err != nil {
+		return nil, err
+	}
+	return &serverWebSocketConn{Conn: conn, reader: reader}, nil
+}
+
+// serverWebSocketConn 服务端 WebSocket 连接：读取带掩码的帧，写入不带掩码的二进制帧
+type serverWebSocketConn struct {
+	net.Conn
+	reader  *bufio.Reader
+	pending []byte
+}
+
+func (c *serverWebSocketConn) Read(b []byte) (int, error) {
+	for len(c.pending) == 0 {
+		var head [2]byte
+		if _, err := io.ReadFull(c.reader, head[:]); err != nil {
+			return 0, err
+		}
+		if head[1]&0x80 == 0 {
+			return 0, errors.New("client frame is not masked")
+		}
+		length := uint64(head[1] & 0x7F)
+		switch length {
+		case 126:
+			var extended [2]byte
+			if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+				return 0, err
+			}
+			length = uint64(binary.BigEndian.Uint16(extended[:]))
+		case 127:
+			var extended [8]byte
+			if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+				return 0, err
+			}
+			length = binary.BigEndian.Uint64(extended[:])
+		}
+		var mask [4]byte
+		if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+			return 0, err
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(c.reader, payload); err != nil {
+			return 0, err
+		}
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+		c.pending = payload
+	}
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *serverWebSocketConn) Write(b []byte) (int, error) {
+	frame := []byte{0x82}
+	switch {
+	case len(b) < 126:
+		frame = append(frame, byte(len(b)))
+	case len(b) <= 0xFFFF:
+		frame = binary.BigEndian.AppendUint16(append(frame, 126), uint16(len(b)))
+	default:
+		frame = binary.BigEndian.AppendUint64(append(frame, 127), uint64(len(b)))
+	}
+	if _, err := c.Conn.Write(append(frame, b...)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func TestCheckSingleNodeProxyTest(t *testing.T) {
+	targetHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	httpTarget := httptest.NewServer(targetHandler)
+	defer httpTarget.Close()
+	httpsTarget := httptest.NewTLSServer(targetHandler)
+	defer httpsTarget.Close()
+	// 回落站点无法解析协议请求头，与 nginx 等站点一样返回 400
+	fallbackSite := httptest.NewServer(http.NotFoundHandler())
+	defer fallbackSite.Close()
+	fallback := fallbackSite.Listener.Addr().String()
+
+	nodeCert := newTestCertificate(t, "node.example.com", time.Now().Add(90*24*time.Hour))
+	trustCertificates(t, nodeCert.Leaf, httpsTarget.Certificate())
+	serverTLS := &tls.Config{Certificates: []tls.Certificate{nodeCert}}
+
+	// http 测试地址使用域名，覆盖域名类型的目标地址编码
+	_, httpPort, _ := net.SplitHostPort(httpTarget.Listener.Addr().String())
+	httpURL := "http://localhost:" + httpPort + "/generate_204"
+	httpsURL := httpsTarget.URL + "/generate_204"
+
+	type server struct {
+		handler   proxyHandler
+		tls       bool
+		webSocket bool
+	}
+	tests := []struct {
+		name       string
+		server     server
+		node       ProxyNode
+		testURL    string
+		wantStatus string
+		wantCode   int
+	}{
+		{"vmess", server{handler: vmessHandler}, ProxyNode{Type: "vmess", UUID: testProxyUUID, Cipher: "auto"}, httpURL, "online", 204},
+		{"vmess wrong uuid", server{handler: vmessHandler}, ProxyNode{Type: "vmess", UUID: "00000000-0000-0000-0000-000000000000"}, httpURL, "auth_failed", 0},
+		{"vmess ws tls https", server{handler: vmessHandler, tls: true, webSocket: true}, ProxyNode{Type: "vmess", UUID: testProxyUUID, Network: "ws"}, httpsURL, "online", 204},
+		{"vless", server{handler: vlessHandler}, ProxyNode{Type: "vless", UUID: testProxyUUID}, httpURL, "online", 204},
+		{"vless wrong uuid fallback", server{handler: vlessHandler, tls: true}, ProxyNode{Type: "vless", UUID: "00000000-0000-0000-0000-000000000000"}, httpURL, "auth_failed", 0},
+		{"vless ws", server{handler: vlessHandler, webSocket: true}, ProxyNode{Type: "vless", UUID: testProxyUUID, Network: "ws"}, httpURL, "online", 204},
+		{"trojan", server{handler: trojanHandler, tls: true}, ProxyNode{Type: "trojan", Password: testProxyPassword}, httpURL, "online", 204},
+		{"trojan https", server{handler: trojanHandler, tls: true}, ProxyNode{Type: "trojan", Password: testProxyPassword}, httpsURL, "online", 204},
+		{"trojan https target returns 400", server{handler: trojanHandler, tls: true}, ProxyNode{Type: "trojan", Password: testProxyPassword}, httpsTarget.URL + "/bad", "online", 400},
+		{"trojan wrong password fallback http", server{handler: trojanHandler, tls: true}, ProxyNode{Type: "trojan", Password: "wrong"}, httpURL, "auth_failed", 0},
+		{"trojan wrong password fallback https", server{handler: trojanHandler, tls: true}, ProxyNode{Type: "trojan", Password: "wrong"}, httpsURL, "auth_failed", 0},
+		{"trojan ws", server{handler: trojanHandler, tls: true, webSocket: true}, ProxyNode{Type: "trojan", Password: testProxyPassword, Network: "ws"}, httpURL, "online", 204},
+		{"trojan ws wrong path", server{handler: trojanHandler, tls: true, webSocket: true}, ProxyNode{Type: "trojan", Password: testProxyPassword, Network: "ws", WSOpts: &struct {
+			Path    string            `yaml:"path"`
+			Headers map[string]string `yaml:"headers,omitempty"`
+		}{Path: "/other"}}, httpURL, "offline", 0},
+		{"ss aes-128-gcm", server{handler: shadowsocksHandler("aes-128-gcm")}, ProxyNode{Type: "ss", Cipher: "aes-128-gcm", Password: testProxyPassword}, httpURL, "online", 204},
+		{"ss chacha20-ietf-poly1305 https", server{handler: shadowsocksHandler("chacha20-ietf-poly1305")}, ProxyNode{Type: "ss", Cipher: "chacha20-ietf-poly1305", Password: testProxyPassword}, httpsURL, "online", 204},
+		{"ss wrong password", server{handler: shadowsocksHandler("aes-256-gcm")}, ProxyNode{Type: "ss", Cipher: "aes-256-gcm", Password: "wrong"}, httpURL, "auth_failed", 0},
+		{"ss wrong cipher", server{handler: shadowsocksHandler("aes-256-gcm")}, ProxyNode{Type: "ss", Cipher: "aes-128-gcm", Password: testProxyPassword}, httpURL, "auth_failed", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tlsConfig *tls.Config
+			if tt.server.tls {
+				tlsConfig = serverTLS
+			}
+			node := tt.node
+			node.Name = tt.name
+			node.Server, node.Port = startProxyServer(t, tt.server.handler, tlsConfig, tt.server.webSocket, fallback)
+			if tt.server.tls {
+				tlsEnabled := true
+				node.TLS, node.SNI = &tlsEnabled, "node.example.com"
+			}
+			if node.Network == "ws" && node.WSOpts == nil {
+				node.WSOpts = &struct {
+					Path    string            `yaml:"path"`
+					Headers map[string]string `yaml:"headers,omitempty"`
+				}{Path: testWebSocketPath, Headers: map[string]string{"Host": "node.example.com"}}
+			}
+
+			status := checkSingleNode(node, tt.testURL)
+			if status.Status != tt.wantStatus {
+				t.Fatalf("status = %s (%s), want %s", status.Status, status.Error, tt.wantStatus)
+			}
+			if status.ProxyTest == nil || status.ProxyTest.Skipped != "" {
+				t.Fatalf("ProxyTest = %+v", status.ProxyTest)
+			}
+			if status.ProxyTest.StatusCode != tt.wantCode {
+				t.Errorf("StatusCode = %d, want %d", status.ProxyTest.StatusCode, tt.wantCode)
+			}
+			if tt.wantStatus == "online" && status.Latency != status.ProxyTest.Latency {
+				t.Errorf("Latency = %d, want end-to-end latency %d", status.Latency, status.ProxyTest.Latency)
+			}
+		})
+	}
+}
+
+func TestProxyTestUnsupported(t *testing.T) {
+	tests := []struct {
+		node        ProxyNode
+		unsupported bool
+	}{
+		{ProxyNode{Type: "vmess", Network: "ws"}, false},
+		{ProxyNode{Type: "ss", Cipher: "AES-128-GCM"}, false},
+		{ProxyNode{Type: "ss", Cipher: "2022-blake3-aes-128-gcm"}, true},
+		{ProxyNode{Type: "ss", Cipher: "aes-128-gcm", Plugin: "obfs"}, true},
+		{ProxyNode{Type: "vless", Flow: "xtls-rprx-vision"}, true},
+		{ProxyNode{Type: "trojan", Network: "grpc"}, true},
+		{ProxyNode{Type: "hysteria2"}, true},
+	}
+	for _, tt := range tests {
+		if reason := proxyTestUnsupported(tt.node); (reason != "") != tt.unsupported {
+			t.Errorf("proxyTestUnsupported(%+v) = %q", tt.node, reason)
+		}
+	}
+}
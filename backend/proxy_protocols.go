@@ -0,0 +1,537 @@
+// backend/proxy_protocols.go
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// proxyTarget 通过代理访问的目标地址
+type proxyTarget struct {
+	host string
+	port int
+}
+
+// encodeAddress 编码目标地址，各协议 IPv4、域名、IPv6 的类型编号不同
+func (t proxyTarget) encodeAddress(ipv4, domain, ipv6 byte) []byte {
+	if ip := net.ParseIP(t.host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			return append([]byte{ipv4}, ip4...)
+		}
+		return append([]byte{ipv6}, ip.To16()...)
+	}
+	return append([]byte{domain, byte(len(t.host))}, t.host...)
+}
+
+// portBytes 大端序端口
+func (t proxyTarget) portBytes() []byte {
+	return binary.BigEndian.AppendUint16(nil, uint16(t.port))
+}
+
+// headerConn 在第一次写入时把协议请求头与数据一起发送
+type headerConn struct {
+	net.Conn
+	header []byte
+}
+
+func (c *headerConn) Write(b []byte) (int, error) {
+	if c.header == nil {
+		return c.Conn.Write(b)
+	}
+	data := append(c.header, b...)
+	c.header = nil
+	if _, err := c.Conn.Write(data); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// newTrojanConn Trojan 请求头: hex(SHA224(密码)) CRLF CONNECT 目标地址 CRLF
+func newTrojanConn(conn net.Conn, node ProxyNode, target proxyTarget) (net.Conn, error) {
+	sum := sha256.Sum224([]byte(node.Password))
+	header := []byte(hex.EncodeToString(sum[:]) + "\r\n")
+	header = append(header, 0x01)
+	header = append(header, target.encodeAddress(0x01, 0x03, 0x04)...)
+	header = append(header, target.portBytes()...)
+	header = append(header, '\r', '\n')
+	return &headerConn{Conn: conn, header: header}, nil
+}
+
+// vlessConn VLESS 连接，响应以版本号与附加信息开头
+type vlessConn struct {
+	headerConn
+	responseRead bool
+}
+
+// newVLESSConn VLESS 请求头: 版本 0、UUID、附加信息长度 0、TCP 命令、端口、目标地址
+func newVLESSConn(conn net.Conn, node ProxyNode, target proxyTarget) (net.Conn, error) {
+	id, err := parseProxyUUID(node.UUID)
+	if err != nil {
+		return nil, err
+	}
+	header := append([]byte{0x00}, id[:]...)
+	header = append(header, 0x00, 0x01)
+	header = append(header, target.portBytes()...)
+	header = append(header, target.encodeAddress(0x01, 0x02, 0x03)...)
+	return &vlessConn{headerConn: headerConn{Conn: conn, header: header}}, nil
+}
+
+func (c *vlessConn) Read(b []byte) (int, error) {
+	if !c.responseRead {
+		var head [2]byte
+		if _, err := io.ReadFull(c.Conn, head[:]); err != nil {
+			return 0, err
+		}
+		if head[0] != 0x00 {
+			// 认证失败的请求会被转发到回落站点，收到的是回落站点的数据
+			return 0, fmt.Errorf("%w: VLESS 响应版本 %d 无效", errProxyAuth, head[0])
+		}
+		if _, err := io.CopyN(io.Discard, c.Conn, int64(head[1])); err != nil {
+			return 0, err
+		}
+		c.responseRead = true
+	}
+	return c.Conn.Read(b)
+}
+
+// parseProxyUUID 解析 VMess/VLESS 的用户 ID
+// 与 Xray 一致，非 UUID 格式的 1-30 字节字符串按全零命名空间映射为 UUIDv5
+func parseProxyUUID(value string) ([16]byte, error) {
+	var id [16]byte
+	if digits := strings.ReplaceAll(value, "-", ""); len(digits) == 32 {
+		if _, err := hex.Decode(id[:], []byte(digits)); err == nil {
+			return id, nil
+		}
+	}
+	if value == "" || len(value) > 30 {
+		return id, fmt.Errorf("UUID %s 无效", value)
+	}
+	sum := sha1.Sum(append(make([]byte, 16), value...))
+	copy(id[:], sum[:16])
+	id[6] = id[6]&0x0f | 0x50
+	id[8] = id[8]&0x3f | 0x80
+	return id, nil
+}
+
+// shadowsocksCipher Shadowsocks AEAD 加密方式
+type shadowsocksCipher struct {
+	keySize int
+	newAEAD func(key []byte) (cipher.AEAD, error)
+}
+
+// shadowsocksCiphers 支持协议测试的加密方式，不含流加密与 2022 系列
+var shadowsocksCiphers = map[string]shadowsocksCipher{
+	"aes-128-gcm":             {keySize: 16, newAEAD: newAESGCM},
+	"aes-192-gcm":             {keySize: 24, newAEAD: newAESGCM},
+	"aes-256-gcm":             {keySize: 32, newAEAD: newAESGCM},
+	"chacha20-ietf-poly1305":  {keySize: 32, newAEAD: chacha20poly1305.New},
+	"xchacha20-ietf-poly1305": {keySize: 32, newAEAD: chacha20poly1305.NewX},
+}
+
+// shadowsocksMaxPayload AEAD 分块的最大载荷长度
+const shadowsocksMaxPayload = 0x3FFF
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// shadowsocksConn Shadowsocks AEAD 连接：盐 + [加密长度][加密载荷]...
+type shadowsocksConn struct {
+	net.Conn
+	cipher shadowsocksCipher
+	key    []byte
+	header []byte // 目标地址，随第一次写入发送
+
+	writer      cipher.AEAD
+	writeNonce  []byte
+	reader      cipher.AEAD
+	readNonce   []byte
+	readPending []byte
+}
+
+func newShadowsocksConn(conn net.Conn, node ProxyNode, target proxyTarget) (net.Conn, error) {
+	ssCipher, ok := shadowsocksCiphers[strings.ToLower(node.Cipher)]
+	if !ok {
+		return nil, fmt.Errorf("不支持的加密方式: %s", node.Cipher)
+	}
+	header := target.encodeAddress(0x01, 0x03, 0x04)
+	header = append(header, target.portBytes()...)
+	return &shadowsocksConn{
+		Conn:   conn,
+		cipher: ssCipher,
+		key:    evpBytesToKey(node.Password, ssCipher.keySize),
+		header: header,
+	}, nil
+}
+
+// subkeyAEAD 由主密钥与盐派生会话密钥
+func (c *shadowsocksConn) subkeyAEAD(salt []byte) (cipher.AEAD, error) {
+	subkey := make([]byte, c.cipher.keySize)
+	if _, err := io.ReadFull(hkdf.New(sha1.New, c.key, salt, []byte("ss-subkey")), subkey); err != nil {
+		return nil, err
+	}
+	return c.cipher.newAEAD(subkey)
+}
+
+func (c *shadowsocksConn) Write(b []byte) (int, error) {
+	var out []byte
+	if c.writer == nil {
+		salt := make([]byte, c.cipher.keySize)
+		if _, err := rand.Read(salt); err != nil {
+			return 0, err
+		}
+		writer, err := c.subkeyAEAD(salt)
+		if err != nil {
+			return 0, err
+		}
+		c.writer, c.writeNonce = writer, make([]byte, writer.NonceSize())
+		out = salt
+	}
+
+	payload := append(c.header, b...)
+	c.header = nil
+	for len(payload) > 0 {
+		chunk := payload
+		if len(chunk) > shadowsocksMaxPayload {
+			chunk = chunk[:shadowsocksMaxPayload]
+		}
+		payload = payload[len(chunk):]
+		out = c.writer.Seal(out, c.writeNonce, binary.BigEndian.AppendUint16(nil, uint16(len(chunk))), nil)
+		increaseNonce(c.writeNonce)
+		out = c.writer.Seal(out, c.writeNonce, chunk, nil)
+		increaseNonce(c.writeNonce)
+	}
+	if _, err := c.Conn.Write(out); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *shadowsocksConn) Read(b []byte) (int, error) {
+	if len(c.readPending) == 0 {
+		if c.reader == nil {
+			salt := make([]byte, c.cipher.keySize)
+			if _, err := io.ReadFull(c.Conn, salt); err != nil {
+				return 0, err
+			}
+			reader, err := c.subkeyAEAD(salt)
+			if err != nil {
+				return 0, err
+			}
+			c.reader, c.readNonce = reader, make([]byte, reader.NonceSize())
+		}
+
+		sealedLength := make([]byte, 2+c.reader.Overhead())
+		if _, err := io.ReadFull(c.Conn, sealedLength); err != nil {
+			return 0, err
+		}
+		length, err := c.reader.Open(nil, c.readNonce, sealedLength, nil)
+		if err != nil {
+			return 0, fmt.Errorf("%w: 响应解密失败", errProxyAuth)
+		}
+		increaseNonce(c.readNonce)
+
+		sealed := make([]byte, int(binary.BigEndian.Uint16(length))+c.reader.Overhead())
+		if _, err := io.ReadFull(c.Conn, sealed); err != nil {
+			return 0, err
+		}
+		if c.readPending, err = c.reader.Open(nil, c.readNonce, sealed, nil); err != nil {
+			return 0, fmt.Errorf("%w: 响应解密失败", errProxyAuth)
+		}
+		increaseNonce(c.readNonce)
+	}
+
+	n := copy(b, c.readPending)
+	c.readPending = c.readPending[n:]
+	return n, nil
+}
+
+// evpBytesToKey OpenSSL EVP_BytesToKey (MD5) 由密码生成主密钥
+func evpBytesToKey(password string, keySize int) []byte {
+	var key, prev []byte
+	for len(key) < keySize {
+		h := md5.New()
+		h.Write(prev)
+		h.Write([]byte(password))
+		prev = h.Sum(nil)
+		key = append(key, prev...)
+	}
+	return key[:keySize]
+}
+
+// increaseNonce 小端序递增 nonce
+func increaseNonce(nonce []byte) {
+	for i := range nonce {
+		nonce[i]++
+		if nonce[i] != 0 {
+			return
+		}
+	}
+}
+
+// VMess AEAD 密钥派生使用的常量
+const (
+	vmessKDFSalt           = "VMess AEAD KDF"
+	vmessAuthIDKey         = "AES Auth ID Encryption"
+	vmessHeaderLengthKey   = "VMess Header AEAD Key_Length"
+	vmessHeaderLengthNonce = "VMess Header AEAD Nonce_Length"
+	vmessHeaderKey         = "VMess Header AEAD Key"
+	vmessHeaderNonce       = "VMess Header AEAD Nonce"
+	vmessRespLengthKey     = "AEAD Resp Header Len Key"
+	vmessRespLengthNonce   = "AEAD Resp Header Len IV"
+	vmessRespHeaderKey     = "AEAD Resp Header Key"
+	vmessRespHeaderNonce   = "AEAD Resp Header IV"
+	vmessCmdKeySalt        = "c48619fe-8f02-49e0-b9e9-edf763e17e21"
+)
+
+// VMess 请求头选项与加密方式
+const (
+	vmessOptionChunkStream = 0x01
+	vmessSecurityAES128GCM = 0x03
+	vmessMaxChunkPayload   = 16 * 1024
+)
+
+// vmessConn VMess AEAD 连接，请求体使用 aes-128-gcm 分块加密
+// 加密方式由客户端选择，服务端均支持，因此不论节点的 cipher 设置如何都使用 aes-128-gcm；
+// alterId 大于 0 的旧版 MD5 认证已被主流服务端移除，同样按 AEAD 发送
+type vmessConn struct {
+	net.Conn
+	header []byte // 已加密的请求头，随第一次写入发送
+
+	requestAEAD  cipher.AEAD
+	requestIV    []byte
+	requestCount uint16
+
+	responseKey   []byte
+	responseIV    []byte
+	responseAuth  byte
+	responseAEAD  cipher.AEAD
+	responseCount uint16
+	responseRead  bool
+	responseEOF   bool
+	readPending   []byte
+}
+
+func newVMessConn(conn net.Conn, node ProxyNode, target proxyTarget) (net.Conn, error) {
+	id, err := parseProxyUUID(node.UUID)
+	if err != nil {
+		return nil, err
+	}
+	cmdKey := md5.Sum(append(id[:], vmessCmdKeySalt...))
+
+	random := make([]byte, 33+16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	requestIV, requestKey, responseAuth := random[:16], random[16:32], random[32]
+	paddingLength := int(random[33] % 16)
+
+	header := []byte{0x01}
+	header = append(header, requestIV...)
+	header = append(header, requestKey...)
+	header = append(header, responseAuth, vmessOptionChunkStream, byte(paddingLength<<4)|vmessSecurityAES128GCM, 0x00, 0x01)
+	header = append(header, target.portBytes()...)
+	header = append(header, target.encodeAddress(0x01, 0x02, 0x03)...)
+	header = append(header, random[34:34+paddingLength]...)
+	checksum := fnv.New32a()
+	checksum.Write(header)
+	header = checksum.Sum(header)
+
+	requestAEAD, err := newAESGCM(requestKey)
+	if err != nil {
+		return nil, err
+	}
+	responseKey := sha256.Sum256(requestKey)
+	responseIV := sha256.Sum256(requestIV)
+	responseAEAD, err := newAESGCM(responseKey[:16])
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := sealVMessHeader(cmdKey[:], header)
+	if err != nil {
+		return nil, err
+	}
+	return &vmessConn{
+		Conn:         conn,
+		header:       sealed,
+		requestAEAD:  requestAEAD,
+		requestIV:    requestIV,
+		responseKey:  responseKey[:16],
+		responseIV:   responseIV[:16],
+		responseAuth: responseAuth,
+		responseAEAD: responseAEAD,
+	}, nil
+}
+
+// vmessKDF VMess AEAD 的嵌套 HMAC-SHA256 密钥派生
+func vmessKDF(key []byte, path ...string) []byte {
+	newHash := func() hash.Hash { return hmac.New(sha256.New, []byte(vmessKDFSalt)) }
+	for _, value := range path {
+		parent, value := newHash, []byte(value)
+		newHash = func() hash.Hash { return hmac.New(parent, value) }
+	}
+	h := newHash()
+	h.Write(key)
+	return h.Sum(nil)
+}
+
+// openVMessAEAD 由 key 派生 AES-128-GCM 密钥、由 nonceKey 派生 nonce
+// 请求头两者都由 cmdKey 派生，响应头则分别由响应密钥与响应 IV 派生
+func openVMessAEAD(key, nonceKey []byte, keyPath, noncePath string, extra ...string) (cipher.AEAD, []byte, error) {
+	aead, err := newAESGCM(vmessKDF(key, append([]string{keyPath}, extra...)...)[:16])
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, vmessKDF(nonceKey, append([]string{noncePath}, extra...)...)[:12], nil
+}
+
+// sealVMessHeader 加密请求头: AuthID + 加密长度 + 连接 nonce + 加密请求头
+func sealVMessHeader(cmdKey, header []byte) ([]byte, error) {
+	authID := make([]byte, 16)
+	binary.BigEndian.PutUint64(authID, uint64(time.Now().Unix()))
+	if _, err := rand.Read(authID[8:12]); err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint32(authID[12:], crc32.ChecksumIEEE(authID[:12]))
+	block, err := aes.NewCipher(vmessKDF(cmdKey, vmessAuthIDKey)[:16])
+	if err != nil {
+		return nil, err
+	}
+	block.Encrypt(authID, authID)
+
+	connectionNonce := make([]byte, 8)
+	if _, err := rand.Read(connectionNonce); err != nil {
+		return nil, err
+	}
+
+	lengthAEAD, lengthNonce, err := openVMessAEAD(cmdKey, cmdKey, vmessHeaderLengthKey, vmessHeaderLengthNonce, string(authID), string(connectionNonce))
+	if err != nil {
+		return nil, err
+	}
+	headerAEAD, headerNonce, err := openVMessAEAD(cmdKey, cmdKey, vmessHeaderKey, vmessHeaderNonce, string(authID), string(connectionNonce))
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := append([]byte(nil), authID...)
+	sealed = lengthAEAD.Seal(sealed, lengthNonce, binary.BigEndian.AppendUint16(nil, uint16(len(header))), authID)
+	sealed = append(sealed, connectionNonce...)
+	return headerAEAD.Seal(sealed, headerNonce, header, authID), nil
+}
+
+// vmessChunkNonce 分块 nonce 为两字节计数与 IV 的第 2-12 字节
+func vmessChunkNonce(iv []byte, count uint16) []byte {
+	nonce := append([]byte(nil), iv[:12]...)
+	binary.BigEndian.PutUint16(nonce, count)
+	return nonce
+}
+
+func (c *vmessConn) Write(b []byte) (int, error) {
+	out := c.header
+	c.header = nil
+	for payload := b; len(payload) > 0; {
+		chunk := payload
+		if len(chunk) > vmessMaxChunkPayload {
+			chunk = chunk[:vmessMaxChunkPayload]
+		}
+		payload = payload[len(chunk):]
+		out = binary.BigEndian.AppendUint16(out, uint16(len(chunk)+c.requestAEAD.Overhead()))
+		out = c.requestAEAD.Seal(out, vmessChunkNonce(c.requestIV, c.requestCount), chunk, nil)
+		c.requestCount++
+	}
+	if _, err := c.Conn.Write(out); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// readResponseHeader 读取并校验响应头，解密失败说明服务端未接受请求
+func (c *vmessConn) readResponseHeader() error {
+	lengthAEAD, lengthNonce, err := openVMessAEAD(c.responseKey, c.responseIV, vmessRespLengthKey, vmessRespLengthNonce)
+	if err != nil {
+		return err
+	}
+	sealedLength := make([]byte, 2+lengthAEAD.Overhead())
+	if _, err := io.ReadFull(c.Conn, sealedLength); err != nil {
+		return err
+	}
+	length, err := lengthAEAD.Open(nil, lengthNonce, sealedLength, nil)
+	if err != nil {
+		return fmt.Errorf("%w: 响应头解密失败", errProxyAuth)
+	}
+
+	headerAEAD, headerNonce, err := openVMessAEAD(c.responseKey, c.responseIV, vmessRespHeaderKey, vmessRespHeaderNonce)
+	if err != nil {
+		return err
+	}
+	sealedHeader := make([]byte, int(binary.BigEndian.Uint16(length))+headerAEAD.Overhead())
+	if _, err := io.ReadFull(c.Conn, sealedHeader); err != nil {
+		return err
+	}
+	header, err := headerAEAD.Open(nil, headerNonce, sealedHeader, nil)
+	if err != nil || len(header) < 4 || header[0] != c.responseAuth {
+		return fmt.Errorf("%w: 响应头无效", errProxyAuth)
+	}
+	return nil
+}
+
+func (c *vmessConn) Read(b []byte) (int, error) {
+	if !c.responseRead {
+		if err := c.readResponseHeader(); err != nil {
+			return 0, err
+		}
+		c.responseRead = true
+	}
+
+	for len(c.readPending) == 0 {
+		if c.responseEOF {
+			return 0, io.EOF
+		}
+		var size [2]byte
+		if _, err := io.ReadFull(c.Conn, size[:]); err != nil {
+			return 0, err
+		}
+		sealed := make([]byte, binary.BigEndian.Uint16(size[:]))
+		if _, err := io.ReadFull(c.Conn, sealed); err != nil {
+			return 0, err
+		}
+		chunk, err := c.responseAEAD.Open(nil, vmessChunkNonce(c.responseIV, c.responseCount), sealed, nil)
+		if err != nil {
+			return 0, errors.New("VMess 响应数据解密失败")
+		}
+		c.responseCount++
+		// 空分块表示数据结束
+		if len(chunk) == 0 {
+			c.responseEOF = true
+		}
+		c.readPending = chunk
+	}
+
+	n := copy(b, c.readPending)
+	c.readPending = c.readPending[n:]
+	return n, nil
+}
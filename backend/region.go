@@ -0,0 +1,118 @@
+// backend/region.go
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Region 节点所在的国家或地区
+type Region struct {
+	Code     string   // ISO 3166-1 代码，用于生成旗帜 emoji
+	Name     string   // 中文名称
+	Keywords []string // 中英文名称与城市名，不区分大小写
+	Codes    []string // 国家/地区代码与机场三字码，需作为独立单词出现 (如 HK01、[NRT])
+
+	codePattern *regexp.Regexp
+}
+
+// Flag 返回地区的旗帜 emoji
+func (r Region) Flag() string {
+	var flag strings.Builder
+	for _, c := range r.Code {
+		flag.WriteRune(0x1F1E6 + c - 'A')
+	}
+	return flag.String()
+}
+
+// GroupName 返回地区代理组名称，如 "🇭🇰 香港"
+func (r Region) GroupName() string {
+	return r.Flag() + " " + r.Name
+}
+
+// regions 支持识别的地区，顺序即代理组的输出顺序
+var regions = []*Region{
+	{Code: "HK", Name: "香港", Keywords: []string{"香港", "Hong Kong", "HongKong"}, Codes: []string{"HK", "HKG"}},
+	{Code: "TW", Name: "台湾", Keywords: []string{"台湾", "台灣", "台北", "新北", "彰化", "Taiwan", "Taipei"}, Codes: []string{"TW", "TPE", "TSA"}},
+	{Code: "JP", Name: "日本", Keywords: []string{"日本", "东京", "東京", "大阪", "Japan", "Tokyo", "Osaka"}, Codes: []string{"JP", "NRT", "HND", "KIX"}},
+	{Code: "SG", Name: "新加坡", Keywords: []string{"新加坡", "狮城", "獅城", "Singapore"}, Codes: []string{"SG", "SIN"}},
+	{Code: "US", Name: "美国", Keywords: []string{"美国", "美國", "洛杉矶", "硅谷", "圣何塞", "西雅图", "纽约", "芝加哥", "达拉斯",
+		"United States", "America", "Los Angeles", "San Jose", "Silicon Valley", "Seattle", "New York", "Chicago", "Dallas"},
+		Codes: []string{"US", "USA", "LAX", "SJC", "SFO", "SEA", "JFK", "ORD", "DFW"}},
+	{Code: "KR", Name: "韩国", Keywords: []string{"韩国", "韓國", "首尔", "首爾", "春川", "Korea", "Seoul"}, Codes: []string{"KR", "KOR", "ICN"}},
+	{Code: "GB", Name: "英国", Keywords: []string{"英国", "英國", "伦敦", "United Kingdom", "Britain", "London"}, Codes: []string{"UK", "LHR"}},
+	{Code: "DE", Name: "德国", Keywords: []string{"德国", "德國", "法兰克福", "Germany", "Frankfurt"}, Codes: []string{"DE", "FRA"}},
+	{Code: "FR", Name: "法国", Keywords: []string{"法国", "法國", "巴黎", "France", "Paris"}, Codes: []string{"FR", "CDG"}},
+	{Code: "NL", Name: "荷兰", Keywords: []string{"荷兰", "荷蘭", "阿姆斯特丹", "Netherlands", "Amsterdam"}, Codes: []string{"NL", "AMS"}},
+	{Code: "CA", Name: "加拿大", Keywords: []string{"加拿大", "多伦多", "温哥华", "Canada", "Toronto", "Vancouver"}, Codes: []string{"CA", "YYZ", "YVR"}},
+	{Code: "AU", Name: "澳大利亚", Keywords: []string{"澳大利亚", "澳洲", "悉尼", "Australia", "Sydney"}, Codes: []string{"AU", "SYD"}},
+	{Code: "RU", Name: "俄罗斯", Keywords: []string{"俄罗斯", "俄羅斯", "莫斯科", "Russia", "Moscow"}, Codes: []string{"RU", "SVO"}},
+	{Code: "IN", Name: "印度", Keywords: []string{"印度", "孟买", "India", "Mumbai"}, Codes: []string{"IN", "BOM"}},
+	{Code: "TR", Name: "土耳其", Keywords: []string{"土耳其", "伊斯坦布尔", "Turkey", "Istanbul"}, Codes: []string{"TR", "IST"}},
+	{Code: "MY", Name: "马来西亚", Keywords: []string{"马来西亚", "馬來西亞", "吉隆坡", "Malaysia", "Kuala Lumpur"}, Codes: []string{"MY", "KUL"}},
+	{Code: "TH", Name: "泰国", Keywords: []string{"泰国", "泰國", "曼谷", "Thailand", "Bangkok"}, Codes: []string{"TH", "BKK"}},
+	{Code: "VN", Name: "越南", Keywords: []string{"越南", "胡志明", "Vietnam", "Ho Chi Minh"}, Codes: []string{"VN", "SGN"}},
+	{Code: "PH", Name: "菲律宾", Keywords: []string{"菲律宾", "菲律賓", "马尼拉", "Philippines", "Manila"}, Codes: []string{"PH", "MNL"}},
+	{Code: "ID", Name: "印尼", Keywords: []string{"印尼", "印度尼西亚", "雅加达", "Indonesia", "Jakarta"}, Codes: []string{"ID", "CGK"}},
+	{Code: "MO", Name: "澳门", Keywords: []string{"澳门", "澳門", "Macau", "Macao"}, Codes: []string{"MO", "MFM"}},
+	{Code: "AR", Name: "阿根廷", Keywords: []string{"阿根廷", "Argentina"}, Codes: []string{"AR", "EZE"}},
+	{Code: "BR", Name: "巴西", Keywords: []string{"巴西", "圣保罗", "Brazil", "Sao Paulo"}, Codes: []string{"BR", "GRU"}},
+}
+
+func init() {
+	for _, region := range regions {
+		// 代码两侧不能紧邻字母，允许紧邻数字与符号
+		region.codePattern = regexp.MustCompile(`(?:^|[^A-Za-z])(?:` + strings.Join(region.Codes, "|") + `)(?:[^A-Za-z]|$)`)
+	}
+}
+
+// classifyRegion 根据节点名称判断所在地区
+// 依次匹配旗帜 emoji、中英文名称、地区代码与机场代码，均未匹配时返回 nil
+func classifyRegion(name string) *Region {
+	if code := flagCode(name); code != "" {
+		for _, region := range regions {
+			if region.Code == code {
+				return region
+			}
+		}
+	}
+
+	// 取最长的匹配关键字，避免 "印度尼西亚" 被识别为 "印度"
+	lowerName := strings.ToLower(name)
+	var matched *Region
+	matchedLength := 0
+	for _, region := range regions {
+		for _, keyword := range region.Keywords {
+			if len(keyword) > matchedLength && strings.Contains(lowerName, strings.ToLower(keyword)) {
+				matched = region
+				matchedLength = len(keyword)
+			}
+		}
+	}
+	if matched != nil {
+		return matched
+	}
+
+	for _, region := range regions {
+		if region.codePattern.MatchString(name) {
+			return region
+		}
+	}
+	return nil
+}
+
+// flagCode 提取名称中第一个旗帜 emoji 对应的地区代码
+func flagCode(name string) string {
+	runes := []rune(name)
+	for i := 0; i+1 < len(runes); i++ {
+		if isRegionalIndicator(runes[i]) && isRegionalIndicator(runes[i+1]) {
+			return string([]rune{'A' + runes[i] - 0x1F1E6, 'A' + runes[i+1] - 0x1F1E6})
+		}
+	}
+	return ""
+}
+
+// isRegionalIndicator 判断字符是否为组成旗帜 emoji 的区域指示符号
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
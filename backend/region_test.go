@@ -0,0 +1,68 @@
+// backend/region_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassifyRegion(t *testing.T) {
+	tests := []struct {
+		name string
+		code string // 为空时不应识别出地区
+	}{
+		{"🇭🇰 香港 01", "HK"},
+		{"🇯🇵 Osaka", "JP"},
+		{"🇺🇸 香港中转", "US"}, // 旗帜优先于关键字
+		{"香港 IPLC 01", "HK"},
+		{"Hong Kong 02", "HK"},
+		{"tokyo-03", "JP"},
+		{"印度尼西亚 01", "ID"}, // 取最长的关键字，不识别为印度
+		{"印度 孟买", "IN"},
+		{"US-LAX 01", "US"},
+		{"[SG] 01", "SG"},
+		{"HK01", "HK"},
+		{"节点 NRT", "JP"},
+		{"CHK 01", ""},  // 代码紧邻字母时不匹配
+		{"USER 01", ""}, // 同上
+		{"剩余流量 10GB", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		region := classifyRegion(tt.name)
+		var code string
+		if region != nil {
+			code = region.Code
+		}
+		if code != tt.code {
+			t.Errorf("classifyRegion(%q) = %q, want %q", tt.name, code, tt.code)
+		}
+	}
+}
+
+func TestBuildRegionGroups(t *testing.T) {
+	names := []string{"US 01", "未知节点", "🇭🇰 01", "JP 01", "香港 02", "US 02"}
+	groups := buildRegionGroups(names)
+
+	// 代理组按 regions 的顺序输出，组内保持节点顺序，无法识别的节点不参与分组
+	want := []string{
+		"🇭🇰 香港: 🇭🇰 01,香港 02",
+		"🇯🇵 日本: JP 01",
+		"🇺🇸 美国: US 01,US 02",
+	}
+	var got []string
+	for _, group := range groups {
+		if group.Type != groupTypeURLTest {
+			t.Errorf("%s: type = %s, want url-test", group.Name, group.Type)
+		}
+		got = append(got, group.Name+": "+strings.Join(group.Proxies, ","))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("groups =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if groups := buildRegionGroups([]string{"未知节点"}); len(groups) != 0 {
+		t.Errorf("groups = %+v, want none", groups)
+	}
+}
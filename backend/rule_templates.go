@@ -0,0 +1,230 @@
+// backend/rule_templates.go
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// 规则模板使用的代理组名称
+const (
+	groupStreaming = "🎬 流媒体"
+	groupAI        = "🤖 AI 服务"
+	groupApple     = "🍎 苹果服务"
+	groupMicrosoft = "Ⓜ️ 微软服务"
+	groupAdBlock   = "🛑 广告拦截"
+)
+
+// ruleProviderInterval 规则集更新间隔 (秒)
+const ruleProviderInterval = 86400
+
+// ClashRuleProvider Clash rule-providers 条目
+type ClashRuleProvider struct {
+	Type     string `yaml:"type"`
+	Behavior string `yaml:"behavior"`
+	URL      string `yaml:"url"`
+	Path     string `yaml:"path"`
+	Interval int    `yaml:"interval"`
+}
+
+// ruleProviderSource 规则集的默认下载地址与类型
+type ruleProviderSource struct {
+	Behavior string // domain、ipcidr 或 classical
+	URL      string
+}
+
+const (
+	loyalsoldierRulesBase = "https://cdn.jsdelivr.net/gh/Loyalsoldier/clash-rules@release/"
+	blackmatrixRulesBase  = "https://cdn.jsdelivr.net/gh/blackmatrix7/ios_rule_script@master/rule/Clash/"
+)
+
+// ruleProviderSources 规则模板可引用的规则集，下载地址可通过 GenerateRequest.RuleProviderURLs 覆盖
+var ruleProviderSources = map[string]ruleProviderSource{
+	"reject":       {Behavior: "domain", URL: loyalsoldierRulesBase + "reject.txt"},
+	"private":      {Behavior: "domain", URL: loyalsoldierRulesBase + "private.txt"},
+	"apple":        {Behavior: "domain", URL: loyalsoldierRulesBase + "apple.txt"},
+	"icloud":       {Behavior: "domain", URL: loyalsoldierRulesBase + "icloud.txt"},
+	"proxy":        {Behavior: "domain", URL: loyalsoldierRulesBase + "proxy.txt"},
+	"gfw":          {Behavior: "domain", URL: loyalsoldierRulesBase + "gfw.txt"},
+	"direct":       {Behavior: "domain", URL: loyalsoldierRulesBase + "direct.txt"},
+	"telegramcidr": {Behavior: "ipcidr", URL: loyalsoldierRulesBase + "telegramcidr.txt"},
+	"cncidr":       {Behavior: "ipcidr", URL: loyalsoldierRulesBase + "cncidr.txt"},
+	"lancidr":      {Behavior: "ipcidr", URL: loyalsoldierRulesBase + "lancidr.txt"},
+	"microsoft":    {Behavior: "classical", URL: blackmatrixRulesBase + "Microsoft/Microsoft.yaml"},
+	"openai":       {Behavior: "classical", URL: blackmatrixRulesBase + "OpenAI/OpenAI.yaml"},
+	"claude":       {Behavior: "classical", URL: blackmatrixRulesBase + "Claude/Claude.yaml"},
+	"streaming":    {Behavior: "classical", URL: blackmatrixRulesBase + "GlobalMedia/GlobalMedia_Classical.yaml"},
+}
+
+// RuleTemplate 规则模板，Rules 中的 RULE-SET 规则决定需要输出的规则集与代理组
+type RuleTemplate struct {
+	Name        string
+	Description string
+	Rules       []string // 不含 MATCH，MATCH 统一指向节点选择
+}
+
+// ruleTemplates 可选的规则模板，default 为内置的少量直连规则
+var ruleTemplates = []RuleTemplate{
+	{
+		Name:        "default",
+		Description: "内置规则：局域网与国内 IP 直连",
+		Rules:       defaultRules(),
+	},
+	{
+		Name:        "minimal",
+		Description: "精简规则：广告拦截、GFW 列表代理、国内直连",
+		Rules: []string{
+			"RULE-SET,private,DIRECT",
+			"RULE-SET,reject," + groupAdBlock,
+			"RULE-SET,gfw," + groupNodeSelect,
+			"RULE-SET,direct," + groupDirect,
+			"RULE-SET,lancidr,DIRECT,no-resolve",
+			"RULE-SET,cncidr," + groupDirect + ",no-resolve",
+			"GEOIP,CN," + groupDirect,
+		},
+	},
+	{
+		Name:        "china-direct",
+		Description: "仅国内直连：国内域名与 IP 直连，其余全部代理",
+		Rules: []string{
+			"RULE-SET,private,DIRECT",
+			"RULE-SET,direct," + groupDirect,
+			"RULE-SET,lancidr,DIRECT,no-resolve",
+			"RULE-SET,cncidr," + groupDirect + ",no-resolve",
+			"GEOIP,CN," + groupDirect,
+		},
+	},
+	{
+		Name:        "full",
+		Description: "完整分流 (ACL4SSR 风格)：广告拦截、AI 服务、流媒体、苹果、微软、Telegram 与国内直连",
+		Rules: []string{
+			"RULE-SET,private,DIRECT",
+			"RULE-SET,reject," + groupAdBlock,
+			"RULE-SET,openai," + groupAI,
+			"RULE-SET,claude," + groupAI,
+			"RULE-SET,streaming," + groupStreaming,
+			"RULE-SET,icloud," + groupApple,
+			"RULE-SET,apple," + groupApple,
+			"RULE-SET,microsoft," + groupMicrosoft,
+			"RULE-SET,proxy," + groupNodeSelect,
+			"RULE-SET,direct," + groupDirect,
+			"RULE-SET,telegramcidr," + groupNodeSelect + ",no-resolve",
+			"RULE-SET,lancidr,DIRECT,no-resolve",
+			"RULE-SET,cncidr," + groupDirect + ",no-resolve",
+			"GEOIP,CN," + groupDirect,
+		},
+	},
+}
+
+// ruleTemplateGroupNames 规则模板可能生成的代理组，自定义代理组不能与其重名
+var ruleTemplateGroupNames = []string{groupStreaming, groupAI, groupApple, groupMicrosoft, groupAdBlock}
+
+// findRuleTemplate 按名称查找规则模板，名称为空时使用 default
+func findRuleTemplate(name string) (*RuleTemplate, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = "default"
+	}
+	for i := range ruleTemplates {
+		if ruleTemplates[i].Name == name {
+			return &ruleTemplates[i], nil
+		}
+	}
+	return nil, fmt.Errorf("不支持的规则模板: %s", name)
+}
+
+// ruleTemplateWarning 规则模板只用于 Clash 与 Clash Premium，其他格式选择了非默认模板时返回提示
+func ruleTemplateWarning(config GenerateRequest) string {
+	ruleTemplate, err := findRuleTemplate(config.RuleTemplate)
+	if err != nil || ruleTemplate.Name == "default" {
+		return ""
+	}
+	format, _ := normalizeFormat(config.Format)
+	if format == "clash" || format == "clash-premium" {
+		return ""
+	}
+	return fmt.Sprintf("规则模板 %s 仅对 Clash 格式生效，%s 配置使用内置规则", ruleTemplate.Name, format)
+}
+
+// validateRuleProviderURLs 校验用户覆盖的规则集地址
+func validateRuleProviderURLs(providerURLs map[string]string) error {
+	for name, rawURL := range providerURLs {
+		if _, ok := ruleProviderSources[name]; !ok {
+			return fmt.Errorf("未知的规则集: %s", name)
+		}
+		parsed, err := url.Parse(rawURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("规则集 %s 的地址无效: %s", name, rawURL)
+		}
+	}
+	return nil
+}
+
+// providerNames 返回模板规则中引用的规则集名称，按首次出现的顺序排列
+func (t RuleTemplate) providerNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, rule := range t.Rules {
+		parts := strings.Split(rule, ",")
+		if len(parts) >= 3 && parts[0] == "RULE-SET" && !seen[parts[1]] {
+			seen[parts[1]] = true
+			names = append(names, parts[1])
+		}
+	}
+	return names
+}
+
+// buildRuleProviders 生成模板需要的 rule-providers，providerURLs 中的地址优先于默认地址
+func (t RuleTemplate) buildRuleProviders(providerURLs map[string]string) map[string]ClashRuleProvider {
+	names := t.providerNames()
+	if len(names) == 0 {
+		return nil
+	}
+
+	providers := make(map[string]ClashRuleProvider, len(names))
+	for _, name := range names {
+		source := ruleProviderSources[name]
+		providerURL := source.URL
+		if override := providerURLs[name]; override != "" {
+			providerURL = override
+		}
+		providers[name] = ClashRuleProvider{
+			Type:     "http",
+			Behavior: source.Behavior,
+			URL:      providerURL,
+			Path:     "./ruleset/" + name + ".yaml",
+			Interval: ruleProviderInterval,
+		}
+	}
+	return providers
+}
+
+// buildGroups 生成模板规则指向的代理组，regionGroupNames 为已生成的地区组，会加入流媒体与 AI 服务组供手动选择
+func (t RuleTemplate) buildGroups(regionGroupNames []string) []ProxyGroup {
+	used := make(map[string]bool)
+	for _, rule := range t.Rules {
+		parts := strings.Split(rule, ",")
+		if len(parts) >= 3 {
+			used[parts[2]] = true
+		}
+	}
+
+	var groups []ProxyGroup
+	for _, name := range ruleTemplateGroupNames {
+		if !used[name] {
+			continue
+		}
+		group := ProxyGroup{Name: name, Type: groupTypeSelect}
+		switch name {
+		case groupStreaming, groupAI:
+			group.Proxies = append([]string{groupNodeSelect, groupAutoSelect}, regionGroupNames...)
+		case groupApple, groupMicrosoft:
+			group.Proxies = []string{groupDirect, groupNodeSelect}
+		case groupAdBlock:
+			group.Proxies = []string{"REJECT", "DIRECT"}
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
@@ -0,0 +1,320 @@
+// backend/rule_validator.go
+package main
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RuleError 自定义规则的校验错误
+type RuleError struct {
+	Line  int    `json:"line"` // 在自定义规则输入中的行号，从 1 开始
+	Rule  string `json:"rule"`
+	Error string `json:"error"`
+}
+
+// builtinPolicies 客户端内置的策略，不需要对应的代理组
+var builtinPolicies = map[string]bool{
+	"DIRECT":      true,
+	"REJECT":      true,
+	"REJECT-DROP": true,
+	"PASS":        true,
+}
+
+// geoCodePattern GEOIP/GEOSITE 的代码，如 CN、LAN、google、category-ads-all
+var geoCodePattern = regexp.MustCompile(`^[A-Za-z0-9_!@.-]+$`)
+
+// ruleValidator 校验单条规则，policies 为可用的策略，providers 为可用的规则集
+type ruleValidator struct {
+	policies  map[string]bool
+	providers map[string]bool
+}
+
+// validateCustomRules 逐行校验自定义规则，返回所有错误
+// 可用策略为当前输出格式实际生成的代理组与内置策略，RULE-SET 只能引用规则模板输出的规则集
+// 代理组按目标格式跳过不支持的节点后生成，与生成器输出的代理组一致
+func validateCustomRules(nodes []ProxyNode, config GenerateRequest) []RuleError {
+	lines := customRuleLines(config.CustomRules)
+	if len(lines) == 0 {
+		return nil
+	}
+
+	validator := ruleValidator{
+		policies:  make(map[string]bool),
+		providers: make(map[string]bool),
+	}
+	for policy := range builtinPolicies {
+		validator.policies[policy] = true
+	}
+
+	// 代理组配置有误时由生成配置时报告，这里只收集能生成的代理组
+	var groups []ProxyGroup
+	format, _ := normalizeFormat(config.Format)
+	nodes = formatNodes(nodes, format)
+	ruleTemplate, err := findRuleTemplate(config.RuleTemplate)
+	if (format == "clash" || format == "clash-premium") && err == nil {
+		groups, _ = buildClashProxyGroups(nodes, config, ruleTemplate)
+		for _, name := range ruleTemplate.providerNames() {
+			validator.providers[name] = true
+		}
+	} else {
+		groups, _ = buildProxyGroups(nodes, config)
+	}
+	for _, group := range groups {
+		validator.policies[group.Name] = true
+	}
+
+	var ruleErrors []RuleError
+	for _, line := range lines {
+		if err := validator.validate(line.Rule); err != nil {
+			ruleErrors = append(ruleErrors, RuleError{Line: line.Line, Rule: line.Rule, Error: err.Error()})
+		}
+	}
+	return ruleErrors
+}
+
+// validate 校验完整规则：类型、参数与策略
+func (v ruleValidator) validate(rule string) error {
+	ruleType, rest, _ := strings.Cut(rule, ",")
+	ruleType = strings.ToUpper(strings.TrimSpace(ruleType))
+
+	switch ruleType {
+	case "MATCH":
+		return fmt.Errorf("MATCH 规则会自动添加在最后，请勿在自定义规则中使用")
+	case "AND", "OR", "NOT":
+		payload, remainder, err := splitLogicPayload(rest)
+		if err != nil {
+			return err
+		}
+		if err := v.validateLogic(ruleType, payload); err != nil {
+			return err
+		}
+		return v.validatePolicy(ruleType, strings.Split(remainder, ","))
+	}
+
+	parts := strings.Split(rest, ",")
+	if len(parts) < 2 {
+		return fmt.Errorf("格式应为 类型,参数,策略")
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	if err := v.validateCondition(ruleType, parts[0]); err != nil {
+		return err
+	}
+	return v.validatePolicy(ruleType, parts[1:])
+}
+
+// validatePolicy 校验策略与附加参数
+func (v ruleValidator) validatePolicy(ruleType string, fields []string) error {
+	policy := strings.TrimSpace(fields[0])
+	if policy == "" {
+		return fmt.Errorf("缺少策略")
+	}
+	if !v.policies[policy] {
+		return fmt.Errorf("策略 %s 不是已生成的代理组或内置策略", policy)
+	}
+
+	for _, option := range fields[1:] {
+		switch option = strings.TrimSpace(option); option {
+		case "no-resolve":
+			switch ruleType {
+			case "IP-CIDR", "IP-CIDR6", "GEOIP", "IP-ASN", "RULE-SET":
+			default:
+				return fmt.Errorf("%s 规则不支持 no-resolve", ruleType)
+			}
+		case "src":
+			switch ruleType {
+			case "IP-CIDR", "IP-CIDR6", "GEOIP", "IP-ASN":
+			default:
+				return fmt.Errorf("%s 规则不支持 src", ruleType)
+			}
+		default:
+			return fmt.Errorf("未知的规则参数: %s", option)
+		}
+	}
+	return nil
+}
+
+// validateCondition 校验不含策略的匹配条件，逻辑规则的子规则也使用此函数
+func (v ruleValidator) validateCondition(ruleType, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s 规则缺少参数", ruleType)
+	}
+
+	switch ruleType {
+	case "DOMAIN", "DOMAIN-SUFFIX", "DOMAIN-KEYWORD":
+		if strings.ContainsAny(value, " \t/") {
+			return fmt.Errorf("域名 %s 无效", value)
+		}
+	case "DOMAIN-REGEX", "PROCESS-NAME-REGEX", "PROCESS-PATH-REGEX":
+		if _, err := regexp.Compile(value); err != nil {
+			return fmt.Errorf("正则表达式 %s 无效: %v", value, err)
+		}
+	case "GEOIP", "GEOSITE", "SRC-GEOIP":
+		if !geoCodePattern.MatchString(value) {
+			return fmt.Errorf("%s 代码 %s 无效", ruleType, value)
+		}
+	case "IP-CIDR", "IP-CIDR6", "SRC-IP-CIDR":
+		ip, _, err := net.ParseCIDR(value)
+		if err != nil {
+			return fmt.Errorf("CIDR %s 无效", value)
+		}
+		if ruleType == "IP-CIDR6" && ip.To4() != nil {
+			return fmt.Errorf("IP-CIDR6 规则需要 IPv6 地址段: %s", value)
+		}
+	case "IP-ASN", "SRC-IP-ASN":
+		if _, err := strconv.ParseUint(value, 10, 32); err != nil {
+			return fmt.Errorf("ASN %s 无效", value)
+		}
+	case "DST-PORT", "SRC-PORT", "IN-PORT":
+		return validatePortRanges(value)
+	case "NETWORK":
+		if network := strings.ToLower(value); network != "tcp" && network != "udp" {
+			return fmt.Errorf("NETWORK 只能是 tcp 或 udp")
+		}
+	case "PROCESS-NAME", "PROCESS-PATH", "IN-TYPE", "IN-NAME", "IN-USER":
+	case "RULE-SET":
+		if !v.providers[value] {
+			return fmt.Errorf("规则集 %s 不存在，只能引用当前规则模板提供的规则集", value)
+		}
+	case "AND", "OR", "NOT":
+		return fmt.Errorf("逻辑规则请使用 %s,((条件),(条件)),策略 的格式", ruleType)
+	default:
+		return fmt.Errorf("不支持的规则类型: %s", ruleType)
+	}
+	return nil
+}
+
+// validateLogic 校验 AND/OR/NOT 规则的子条件
+func (v ruleValidator) validateLogic(ruleType, payload string) error {
+	conditions, err := splitLogicConditions(payload)
+	if err != nil {
+		return err
+	}
+	switch {
+	case ruleType == "NOT" && len(conditions) != 1:
+		return fmt.Errorf("NOT 规则只能包含一个条件")
+	case ruleType != "NOT" && len(conditions) < 2:
+		return fmt.Errorf("%s 规则至少需要两个条件", ruleType)
+	}
+
+	for _, condition := range conditions {
+		conditionType, value, _ := strings.Cut(condition, ",")
+		conditionType = strings.ToUpper(strings.TrimSpace(conditionType))
+		value = strings.TrimSpace(value)
+
+		switch conditionType {
+		case "AND", "OR", "NOT":
+			nested, remainder, err := splitLogicPayload(value)
+			if err != nil {
+				return err
+			}
+			if strings.TrimSpace(remainder) != "" {
+				return fmt.Errorf("子条件 %s 不能包含策略", condition)
+			}
+			if err := v.validateLogic(conditionType, nested); err != nil {
+				return err
+			}
+		default:
+			// 子条件允许携带 no-resolve 等参数
+			value, _, _ = strings.Cut(value, ",")
+			if err := v.validateCondition(conditionType, strings.TrimSpace(value)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// splitLogicPayload 拆分 "((...),(...)),策略" 为括号内的条件列表与其后的内容
+func splitLogicPayload(rest string) (string, string, error) {
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, "(") {
+		return "", "", fmt.Errorf("逻辑规则的条件需要用括号包围")
+	}
+
+	depth := 0
+	for i, c := range rest {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				remainder := strings.TrimSpace(rest[i+1:])
+				if remainder != "" && !strings.HasPrefix(remainder, ",") {
+					return "", "", fmt.Errorf("逻辑规则的括号后应为策略")
+				}
+				return rest[1:i], strings.TrimPrefix(remainder, ","), nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("逻辑规则的括号不匹配")
+}
+
+// splitLogicConditions 拆分 "(DOMAIN,a.com),(NETWORK,udp)" 为各个条件
+func splitLogicConditions(payload string) ([]string, error) {
+	var conditions []string
+	depth, start := 0, -1
+	for i, c := range payload {
+		switch c {
+		case '(':
+			if depth == 0 {
+				start = i + 1
+			}
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("逻辑规则的括号不匹配")
+			}
+			if depth == 0 {
+				conditions = append(conditions, payload[start:i])
+			}
+		case ',', ' ':
+		default:
+			if depth == 0 {
+				return nil, fmt.Errorf("逻辑规则的每个条件都需要用括号包围")
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("逻辑规则的括号不匹配")
+	}
+	return conditions, nil
+}
+
+// validatePortRanges 校验端口或端口范围，多个范围以 / 分隔，如 80/443/8000-9000
+func validatePortRanges(value string) error {
+	for _, portRange := range strings.Split(value, "/") {
+		low, high, isRange := strings.Cut(portRange, "-")
+		lowPort, err := parseRulePort(low)
+		if err != nil {
+			return err
+		}
+		if !isRange {
+			continue
+		}
+		highPort, err := parseRulePort(high)
+		if err != nil {
+			return err
+		}
+		if lowPort > highPort {
+			return fmt.Errorf("端口范围 %s 无效: 起始端口大于结束端口", portRange)
+		}
+	}
+	return nil
+}
+
+// parseRulePort 解析 1-65535 之间的端口
+func parseRulePort(value string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("端口 %s 无效", value)
+	}
+	return port, nil
+}
@@ -0,0 +1,92 @@
+// backend/rule_validator_test.go
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateCustomRules(t *testing.T) {
+	tlsEnabled := true
+	// 香港节点只有 gRPC multi 模式，Clash 与 sing-box 会跳过它，不会生成香港地区组
+	multiNode := ProxyNode{
+		Name:    "香港 multi",
+		Type:    "vmess",
+		Server:  "hk.example.com",
+		Port:    443,
+		UUID:    "b831381d-6324-4d53-ad4f-8cda48b30811",
+		Cipher:  "auto",
+		TLS:     &tlsEnabled,
+		Network: "grpc",
+	}
+	multiNode.GRPCopts = &struct {
+		ServiceName string `yaml:"service-name,omitempty"`
+		Mode        string `yaml:"mode,omitempty"`
+	}{ServiceName: "svc", Mode: "multi"}
+	nodes := []ProxyNode{
+		{Name: "日本 01", Type: "trojan", Server: "jp.example.com", Port: 443, Password: "secret"},
+		multiNode,
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		rule   string
+		errMsg string // 为空时应校验通过
+	}{
+		{"valid", "clash", "DOMAIN-SUFFIX,example.com,🚀 节点选择", ""},
+		{"builtin policy", "sing-box", "IP-CIDR,10.0.0.0/8,DIRECT,no-resolve", ""},
+		{"region group", "clash", "DOMAIN,jp.example.com,🇯🇵 日本", ""},
+		{"unknown group", "clash", "DOMAIN,example.com,不存在的组", "不是已生成的代理组或内置策略"},
+		{"region group of skipped nodes", "clash", "DOMAIN,hk.example.com,🇭🇰 香港", "不是已生成的代理组或内置策略"},
+		{"region group of skipped nodes in sing-box", "sing-box", "DOMAIN,hk.example.com,🇭🇰 香港", "不是已生成的代理组或内置策略"},
+		{"missing policy", "clash", "DOMAIN-SUFFIX,example.com", "格式应为"},
+		{"unknown type", "clash", "DOMAIN-FOO,example.com,DIRECT", "不支持的规则类型"},
+		{"invalid cidr", "clash", "IP-CIDR,10.0.0.0/33,DIRECT", "10.0.0.0/33"},
+		{"port range", "sing-box", "DST-PORT,1000-2000,DIRECT", ""},
+		{"ports and range", "clash", "DST-PORT,80/443/8000-9000,DIRECT", ""},
+		{"invalid port", "clash", "DST-PORT,70000,DIRECT", "端口"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateCustomRules(nodes, GenerateRequest{Format: tt.format, RegionGroups: true, CustomRules: tt.rule})
+			if tt.errMsg == "" {
+				if len(errs) != 0 {
+					t.Errorf("errors = %+v, want none", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Line != 1 || !strings.Contains(errs[0].Error, tt.errMsg) {
+				t.Errorf("errors = %+v, want one error containing %q", errs, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestConvertDstPortRuleToSingBox(t *testing.T) {
+	tests := []struct {
+		rule      string
+		ports     []int
+		portRange []string
+	}{
+		{"DST-PORT,443,DIRECT", []int{443}, nil},
+		{"DST-PORT,1000-2000,DIRECT", nil, []string{"1000:2000"}},
+		{"DST-PORT,80/443/8000-9000,DIRECT", []int{80, 443}, []string{"8000:9000"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, ok := convertRuleToSingBox(tt.rule)
+			if !ok {
+				t.Fatal("rule was dropped")
+			}
+			got, _ := json.Marshal([]any{rule.Port, rule.PortRange})
+			want, _ := json.Marshal([]any{tt.ports, tt.portRange})
+			if string(got) != string(want) {
+				t.Errorf("port, port_range = %s, want %s", got, want)
+			}
+		})
+	}
+}
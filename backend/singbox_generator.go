@@ -0,0 +1,480 @@
+// backend/singbox_generator.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SingBoxConfig sing-box 客户端配置 (面向 sing-box 1.11+)
+type SingBoxConfig struct {
+	Log          SingBoxLog          `json:"log"`
+	DNS          SingBoxDNS          `json:"dns"`
+	Inbounds     []SingBoxInbound    `json:"inbounds"`
+	Outbounds    []SingBoxOutbound   `json:"outbounds"`
+	Route        SingBoxRoute        `json:"route"`
+	Experimental SingBoxExperimental `json:"experimental"`
+}
+
+// SingBoxLog 日志配置
+type SingBoxLog struct {
+	Disabled  bool   `json:"disabled,omitempty"`
+	Level     string `json:"level,omitempty"`
+	Timestamp bool   `json:"timestamp"`
+}
+
+// SingBoxDNS DNS 配置
+type SingBoxDNS struct {
+	Servers          []SingBoxDNSServer `json:"servers"`
+	Rules            []SingBoxDNSRule   `json:"rules"`
+	Final            string             `json:"final"`
+	Strategy         string             `json:"strategy"`
+	IndependentCache bool               `json:"independent_cache"`
+	FakeIP           *SingBoxFakeIP     `json:"fakeip,omitempty"`
+}
+
+// SingBoxDNSServer DNS 服务器
+type SingBoxDNSServer struct {
+	Tag     string `json:"tag"`
+	Address string `json:"address"`
+	Detour  string `json:"detour,omitempty"`
+}
+
+// SingBoxDNSRule DNS 规则
+type SingBoxDNSRule struct {
+	Outbound  string   `json:"outbound,omitempty"`
+	RuleSet   []string `json:"rule_set,omitempty"`
+	QueryType []string `json:"query_type,omitempty"`
+	Server    string   `json:"server"`
+}
+
+// SingBoxFakeIP FakeIP 地址池
+type SingBoxFakeIP struct {
+	Enabled    bool   `json:"enabled"`
+	Inet4Range string `json:"inet4_range"`
+	Inet6Range string `json:"inet6_range,omitempty"`
+}
+
+// SingBoxInbound 入站配置 (mixed/tun)
+type SingBoxInbound struct {
+	Type        string   `json:"type"`
+	Tag         string   `json:"tag"`
+	Listen      string   `json:"listen,omitempty"`
+	ListenPort  int      `json:"listen_port,omitempty"`
+	Address     []string `json:"address,omitempty"`
+	AutoRoute   bool     `json:"auto_route,omitempty"`
+	StrictRoute bool     `json:"strict_route,omitempty"`
+	Stack       string   `json:"stack,omitempty"`
+}
+
+// SingBoxRoute 路由配置
+type SingBoxRoute struct {
+	Rules               []SingBoxRouteRule `json:"rules"`
+	RuleSet             []SingBoxRuleSet   `json:"rule_set,omitempty"`
+	Final               string             `json:"final"`
+	AutoDetectInterface bool               `json:"auto_detect_interface"`
+}
+
+// SingBoxRouteRule 路由规则
+type SingBoxRouteRule struct {
+	Action        string   `json:"action,omitempty"`
+	Protocol      string   `json:"protocol,omitempty"`
+	IPIsPrivate   bool     `json:"ip_is_private,omitempty"`
+	Domain        []string `json:"domain,omitempty"`
+	DomainSuffix  []string `json:"domain_suffix,omitempty"`
+	DomainKeyword []string `json:"domain_keyword,omitempty"`
+	IPCIDR        []string `json:"ip_cidr,omitempty"`
+	Port          []int    `json:"port,omitempty"`
+	PortRange     []string `json:"port_range,omitempty"`
+	ProcessName   []string `json:"process_name,omitempty"`
+	RuleSet       []string `json:"rule_set,omitempty"`
+	Outbound      string   `json:"outbound,omitempty"`
+}
+
+// SingBoxRuleSet 远程规则集
+type SingBoxRuleSet struct {
+	Tag            string `json:"tag"`
+	Type           string `json:"type"`
+	Format         string `json:"format"`
+	URL            string `json:"url"`
+	DownloadDetour string `json:"download_detour,omitempty"`
+}
+
+// SingBoxExperimental 实验性功能 (Clash API 与缓存)
+type SingBoxExperimental struct {
+	ClashAPI struct {
+		ExternalController string `json:"external_controller"`
+	} `json:"clash_api"`
+	CacheFile struct {
+		Enabled bool `json:"enabled"`
+	} `json:"cache_file"`
+}
+
+// sing-box 中直连出站的标签
+const singBoxDirectTag = "DIRECT"
+
+// GenerateSingBoxConfig 生成 sing-box 客户端配置文件
+// 代理组与规则与 GenerateClashConfig 保持一致，Clash 格式的自定义规则会尽量转换为路由规则
+// sing-box 无法表示的节点 (如不支持的 Shadowsocks 插件) 会被跳过并返回
+func GenerateSingBoxConfig(nodes []ProxyNode, configName string, config GenerateRequest) (string, []string, error) {
+	// 设置默认值
+	mixedPort := config.MixedPort
+	if mixedPort == 0 {
+		mixedPort = 7890
+	}
+
+	controllerPort := config.ControllerPort
+	if controllerPort == 0 {
+		controllerPort = 9090
+	}
+
+	dnsMode := config.DNSMode
+	if dnsMode == "" {
+		dnsMode = "fake-ip"
+	}
+
+	singBoxConfig := SingBoxConfig{
+		Log: singBoxLog(config.LogLevel),
+	}
+
+	// DNS 配置：代理服务器域名与国内域名走直连 DNS，其余走远程 DNS
+	singBoxConfig.DNS = SingBoxDNS{
+		Servers: []SingBoxDNSServer{
+			{Tag: "dns-remote", Address: "https://1.1.1.1/dns-query", Detour: groupNodeSelect},
+			{Tag: "dns-direct", Address: "https://223.5.5.5/dns-query"},
+		},
+		Rules: []SingBoxDNSRule{
+			{Outbound: "any", Server: "dns-direct"},
+			{RuleSet: []string{"geosite-cn"}, Server: "dns-direct"},
+		},
+		Final:            "dns-remote",
+		Strategy:         "ipv4_only",
+		IndependentCache: true,
+	}
+	if config.EnableIPv6 {
+		singBoxConfig.DNS.Strategy = "prefer_ipv4"
+	}
+	if dnsMode == "fake-ip" {
+		singBoxConfig.DNS.Servers = append(singBoxConfig.DNS.Servers, SingBoxDNSServer{Tag: "dns-fakeip", Address: "fakeip"})
+		singBoxConfig.DNS.Rules = append(singBoxConfig.DNS.Rules, SingBoxDNSRule{QueryType: []string{"A", "AAAA"}, Server: "dns-fakeip"})
+		singBoxConfig.DNS.FakeIP = &SingBoxFakeIP{Enabled: true, Inet4Range: "198.18.0.0/15", Inet6Range: "fc00::/18"}
+	}
+
+	// 入站配置
+	listen := "127.0.0.1"
+	if config.AllowLan {
+		listen = "0.0.0.0"
+	}
+	singBoxConfig.Inbounds = []SingBoxInbound{
+		{Type: "mixed", Tag: "mixed-in", Listen: listen, ListenPort: mixedPort},
+		{
+			Type:        "tun",
+			Tag:         "tun-in",
+			Address:     []string{"172.19.0.1/30", "fdfe:dcba:9876::1/126"},
+			AutoRoute:   true,
+			StrictRoute: true,
+			Stack:       "mixed",
+		},
+	}
+
+	// 出站配置：代理组在前，节点随后，最后是直连出站
+	var nodeOutbounds []SingBoxOutbound
+	var includedNodes []ProxyNode
+	var skipped []string
+	for _, node := range nodes {
+		outbound, err := buildSingBoxOutbound(node)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", node.Name, err))
+			continue
+		}
+		nodeOutbounds = append(nodeOutbounds, outbound)
+		includedNodes = append(includedNodes, node)
+	}
+	if len(includedNodes) == 0 {
+		return "", skipped, fmt.Errorf("没有 sing-box 支持的节点")
+	}
+
+	proxyGroups, err := buildProxyGroups(includedNodes, config)
+	if err != nil {
+		return "", skipped, err
+	}
+	for _, group := range proxyGroups {
+		if group.Type != groupTypeSelect {
+			// sing-box 没有 fallback 与 load-balance，均以 urltest 代替
+			singBoxConfig.Outbounds = append(singBoxConfig.Outbounds, SingBoxOutbound{
+				Type:      "urltest",
+				Tag:       group.Name,
+				Outbounds: group.Proxies,
+				URL:       group.URL,
+				Interval:  fmt.Sprintf("%ds", group.Interval),
+				Tolerance: group.Tolerance,
+			})
+		} else {
+			// sing-box 的 selector 默认选中第一个出站
+			singBoxConfig.Outbounds = append(singBoxConfig.Outbounds, SingBoxOutbound{
+				Type:      "selector",
+				Tag:       group.Name,
+				Outbounds: group.Proxies,
+			})
+		}
+	}
+	singBoxConfig.Outbounds = append(singBoxConfig.Outbounds, nodeOutbounds...)
+	singBoxConfig.Outbounds = append(singBoxConfig.Outbounds, SingBoxOutbound{Type: "direct", Tag: singBoxDirectTag})
+
+	// 路由配置
+	singBoxConfig.Route = SingBoxRoute{
+		Rules: []SingBoxRouteRule{
+			{Action: "sniff"},
+			{Protocol: "dns", Action: "hijack-dns"},
+			{IPIsPrivate: true, Outbound: singBoxDirectTag},
+			{DomainSuffix: []string{"local"}, Outbound: singBoxDirectTag},
+			{IPCIDR: []string{"17.0.0.0/8", "100.64.0.0/10"}, Outbound: singBoxDirectTag},
+			{DomainSuffix: []string{"cn"}, Outbound: groupDirect},
+			{RuleSet: []string{"geoip-cn", "geosite-cn"}, Outbound: groupDirect},
+		},
+		RuleSet: []SingBoxRuleSet{
+			{
+				Tag:            "geoip-cn",
+				Type:           "remote",
+				Format:         "binary",
+				URL:            "https://raw.githubusercontent.com/SagerNet/sing-geoip/rule-set/geoip-cn.srs",
+				DownloadDetour: groupNodeSelect,
+			},
+			{
+				Tag:            "geosite-cn",
+				Type:           "remote",
+				Format:         "binary",
+				URL:            "https://raw.githubusercontent.com/SagerNet/sing-geosite/rule-set/geosite-cn.srs",
+				DownloadDetour: groupNodeSelect,
+			},
+		},
+		Final:               groupNodeSelect,
+		AutoDetectInterface: true,
+	}
+
+	// 自定义规则，无法在 sing-box 中表达的规则会被跳过
+	for _, rule := range splitCustomRules(config.CustomRules) {
+		if routeRule, ok := convertRuleToSingBox(rule); ok {
+			singBoxConfig.Route.Rules = append(singBoxConfig.Route.Rules, routeRule)
+		}
+	}
+
+	singBoxConfig.Experimental.ClashAPI.ExternalController = fmt.Sprintf("127.0.0.1:%d", controllerPort)
+	singBoxConfig.Experimental.CacheFile.Enabled = true
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(singBoxConfig); err != nil {
+		return "", skipped, fmt.Errorf("生成JSON失败: %w", err)
+	}
+
+	return buf.String(), skipped, nil
+}
+
+// singBoxLog 将 Clash 日志级别转换为 sing-box 日志配置
+func singBoxLog(logLevel string) SingBoxLog {
+	switch logLevel {
+	case "silent":
+		return SingBoxLog{Disabled: true}
+	case "warning":
+		return SingBoxLog{Level: "warn", Timestamp: true}
+	case "":
+		return SingBoxLog{Level: "info", Timestamp: true}
+	default:
+		return SingBoxLog{Level: logLevel, Timestamp: true}
+	}
+}
+
+// buildSingBoxOutbound 将 ProxyNode 转换为 sing-box 出站
+func buildSingBoxOutbound(node ProxyNode) (SingBoxOutbound, error) {
+	outbound := SingBoxOutbound{
+		Tag:        node.Name,
+		Server:     node.Server,
+		ServerPort: node.Port,
+	}
+
+	switch node.Type {
+	case "vmess":
+		outbound.Type = "vmess"
+		outbound.UUID = node.UUID
+		outbound.AlterID = node.AlterID
+		outbound.Security = node.Cipher
+	case "vless":
+		outbound.Type = "vless"
+		outbound.UUID = node.UUID
+		outbound.Flow = node.Flow
+	case "ss":
+		outbound.Type = "shadowsocks"
+		outbound.Method = node.Cipher
+		outbound.Password = node.Password
+		if node.Plugin != "" {
+			plugin, pluginOpts, err := singBoxShadowsocksPlugin(node.Plugin, node.PluginOpts)
+			if err != nil {
+				return outbound, err
+			}
+			outbound.Plugin = plugin
+			outbound.PluginOpts = pluginOpts
+		}
+		return outbound, nil
+	case "trojan":
+		outbound.Type = "trojan"
+		outbound.Password = node.Password
+	case "hysteria2":
+		// sing-box 不能固定证书指纹，丢弃 pinSHA256 会改变证书校验方式，因此跳过该节点
+		if node.CertFingerprint != "" {
+			return outbound, fmt.Errorf("sing-box 不支持 hysteria2 证书指纹 (pinSHA256)")
+		}
+		outbound.Type = "hysteria2"
+		outbound.Password = node.Password
+		if node.Ports != "" {
+			for _, portRange := range strings.Split(node.Ports, ",") {
+				// sing-box 端口范围使用冒号分隔，单个端口也需写成范围
+				start, end, isRange := strings.Cut(strings.TrimSpace(portRange), "-")
+				if !isRange {
+					end = start
+				}
+				outbound.ServerPorts = append(outbound.ServerPorts, start+":"+end)
+			}
+		}
+		if node.Obfs != "" {
+			outbound.Obfs = &SingBoxObfs{Type: node.Obfs, Password: node.ObfsPassword}
+		}
+	case "tuic":
+		outbound.Type = "tuic"
+		outbound.UUID = node.UUID
+		outbound.Password = node.Password
+		outbound.CongestionControl = node.CongestionControl
+		outbound.UDPRelayMode = node.UDPRelayMode
+	default:
+		return outbound, fmt.Errorf("sing-box 不支持的节点类型: %s", node.Type)
+	}
+
+	// TLS 配置
+	if node.TLS != nil && *node.TLS {
+		tls := &SingBoxTLS{
+			Enabled:    true,
+			ServerName: node.SNI,
+			Insecure:   node.SkipCertVerify,
+			ALPN:       node.ALPN,
+		}
+		if node.Fingerprint != "" {
+			tls.UTLS = &SingBoxUTLS{Enabled: true, Fingerprint: node.Fingerprint}
+		}
+		if node.RealityOpts != nil {
+			tls.Reality = &SingBoxReality{
+				Enabled:   true,
+				PublicKey: node.RealityOpts.PublicKey,
+				ShortID:   node.RealityOpts.ShortID,
+			}
+		}
+		outbound.TLS = tls
+	}
+
+	// 传输层配置
+	switch node.Network {
+	case "ws":
+		if node.WSOpts != nil {
+			outbound.Transport = &SingBoxTransport{
+				Type:    "ws",
+				Path:    node.WSOpts.Path,
+				Headers: node.WSOpts.Headers,
+			}
+		}
+	case "grpc":
+		// sing-box 的 gRPC 传输同样只支持 gun 模式
+		if err := checkGRPCMode(node); err != nil {
+			return outbound, err
+		}
+		if node.GRPCopts != nil {
+			outbound.Transport = &SingBoxTransport{
+				Type:        "grpc",
+				ServiceName: node.GRPCopts.ServiceName,
+			}
+		}
+	case "h2", "http":
+		if node.HTTPOpts != nil {
+			transport := &SingBoxTransport{
+				Type:   "http",
+				Path:   node.HTTPOpts.Path,
+				Method: node.HTTPOpts.Method,
+			}
+			if host := node.HTTPOpts.Headers["Host"]; host != "" {
+				transport.Host = []string{host}
+			}
+			outbound.Transport = transport
+		}
+	}
+
+	return outbound, nil
+}
+
+// singBoxShadowsocksPlugin 将 Clash 的 plugin/plugin-opts 转换为 sing-box 支持的 SIP003 插件参数
+func singBoxShadowsocksPlugin(plugin string, opts map[string]interface{}) (string, string, error) {
+	if plugin != "obfs" && plugin != "v2ray-plugin" {
+		return "", "", fmt.Errorf("sing-box 不支持的 Shadowsocks 插件: %s", plugin)
+	}
+	pluginName, pluginOpts := formatShadowsocksPlugin(plugin, opts)
+	return pluginName, pluginOpts, nil
+}
+
+// convertRuleToSingBox 将 Clash 格式的规则转换为 sing-box 路由规则
+func convertRuleToSingBox(rule string) (SingBoxRouteRule, bool) {
+	parts := strings.Split(rule, ",")
+	if len(parts) < 3 {
+		return SingBoxRouteRule{}, false
+	}
+
+	ruleType := strings.ToUpper(strings.TrimSpace(parts[0]))
+	value := strings.TrimSpace(parts[1])
+	target := strings.TrimSpace(parts[2])
+
+	var routeRule SingBoxRouteRule
+	switch ruleType {
+	case "DOMAIN":
+		routeRule.Domain = []string{value}
+	case "DOMAIN-SUFFIX":
+		routeRule.DomainSuffix = []string{value}
+	case "DOMAIN-KEYWORD":
+		routeRule.DomainKeyword = []string{value}
+	case "IP-CIDR", "IP-CIDR6":
+		routeRule.IPCIDR = []string{value}
+	case "DST-PORT":
+		// 多个端口或范围以 / 分隔，范围在 sing-box 中写作 port_range "起始:结束"
+		for _, portRange := range strings.Split(value, "/") {
+			low, high, isRange := strings.Cut(portRange, "-")
+			lowPort, err := strconv.Atoi(strings.TrimSpace(low))
+			if err != nil {
+				return SingBoxRouteRule{}, false
+			}
+			if !isRange {
+				routeRule.Port = append(routeRule.Port, lowPort)
+				continue
+			}
+			highPort, err := strconv.Atoi(strings.TrimSpace(high))
+			if err != nil {
+				return SingBoxRouteRule{}, false
+			}
+			routeRule.PortRange = append(routeRule.PortRange, fmt.Sprintf("%d:%d", lowPort, highPort))
+		}
+	case "PROCESS-NAME":
+		routeRule.ProcessName = []string{value}
+	default:
+		return SingBoxRouteRule{}, false
+	}
+
+	switch strings.ToUpper(target) {
+	case "DIRECT":
+		routeRule.Outbound = singBoxDirectTag
+	case "REJECT", "REJECT-DROP":
+		routeRule.Action = "reject"
+	default:
+		routeRule.Outbound = target
+	}
+
+	return routeRule, true
+}
@@ -0,0 +1,234 @@
+// backend/subscription.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 远程订阅下载的默认限制
+const (
+	subscriptionFetchTimeout = 15 * time.Second
+	subscriptionMaxBodySize  = 10 << 20 // 10MB
+	subscriptionMaxRedirects = 5        // 单次下载最多的请求数，含最初的请求
+	subscriptionMaxURLs      = 20       // 单次请求最多的订阅地址数
+	subscriptionConcurrency  = 4        // 同时下载的订阅数
+)
+
+// SourceResult 单个节点来源 (粘贴的链接或远程订阅) 的处理结果
+type SourceResult struct {
+	Source    string `json:"source"`
+	Success   bool   `json:"success"`
+	NodeCount int    `json:"nodeCount"`
+	Error     string `json:"error,omitempty"`
+}
+
+// SubscriptionFetcher 远程订阅下载器
+type SubscriptionFetcher struct {
+	Client      *http.Client
+	UserAgent   string
+	MaxBodySize int64
+}
+
+// NewSubscriptionFetcher 创建带超时与重定向限制的订阅下载器
+// userAgent 为空时使用 ClashLink 默认标识
+func NewSubscriptionFetcher(userAgent string) *SubscriptionFetcher {
+	if userAgent == "" {
+		userAgent = fmt.Sprintf("ClashLink/%s", getCurrentVersion())
+	}
+
+	// 订阅地址由用户提供，需在连接时 (DNS 解析之后) 拒绝内网地址，防止服务器被用来访问内网或云元数据服务
+	dialer := &net.Dialer{
+		Timeout: subscriptionFetchTimeout,
+		Control: rejectPrivateAddress,
+	}
+
+	return &SubscriptionFetcher{
+		Client: &http.Client{
+			Timeout: subscriptionFetchTimeout,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: subscriptionFetchTimeout,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				// via 包含最初的请求，因此最多跟随 subscriptionMaxRedirects-1 次重定向
+				if len(via) >= subscriptionMaxRedirects {
+					return fmt.Errorf("重定向次数达到 %d 次", subscriptionMaxRedirects)
+				}
+				return nil
+			},
+		},
+		UserAgent:   userAgent,
+		MaxBodySize: subscriptionMaxBodySize,
+	}
+}
+
+// specialUseNetworks 标准库判断方法之外的特殊用途地址段 (RFC 6890)，均不是可访问的公网地址
+var specialUseNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",       // 本网络
+		"100.64.0.0/10",   // 运营商级 NAT (CGNAT)
+		"192.0.0.0/24",    // IETF 协议分配
+		"192.0.2.0/24",    // 文档示例 TEST-NET-1
+		"192.88.99.0/24",  // 6to4 中继任播
+		"198.18.0.0/15",   // 网络性能测试
+		"198.51.100.0/24", // 文档示例 TEST-NET-2
+		"203.0.113.0/24",  // 文档示例 TEST-NET-3
+		"240.0.0.0/4",     // 保留地址，含广播地址 255.255.255.255
+		"64:ff9b:1::/48",  // 本地 NAT64
+		"100::/64",        // 丢弃地址
+		"2001::/23",       // IETF 协议分配，含 Teredo
+		"2001:db8::/32",   // 文档示例
+	} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// nat64Prefix 知名 NAT64 前缀，后 32 位为内嵌的 IPv4 地址
+var nat64Prefix = net.ParseIP("64:ff9b::")
+
+// isPublicIP 判断地址是否为可访问的公网地址，NAT64 与 6to4 地址按内嵌的 IPv4 地址判断
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range specialUseNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	if ip.To4() == nil {
+		if ip[0] == 0x20 && ip[1] == 0x02 {
+			return isPublicIP(net.IP(ip[2:6]))
+		}
+		if ip[:12].Equal(nat64Prefix[:12]) {
+			return isPublicIP(net.IP(ip[12:16]))
+		}
+	}
+	return true
+}
+
+// rejectPrivateAddress 拒绝连接本机、内网、链路本地 (含 169.254.169.254)、CGNAT 等非公网地址
+func rejectPrivateAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("无法识别的地址: %s", host)
+	}
+	if !isPublicIP(ip) {
+		return fmt.Errorf("不允许访问内网或本机地址 %s", host)
+	}
+	return nil
+}
+
+// FetchNodes 下载单个订阅并解析出节点
+func (f *SubscriptionFetcher) FetchNodes(rawURL string) ([]ProxyNode, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return nil, fmt.Errorf("无效的订阅地址")
+	}
+
+	req, err := http.NewRequest("GET", parsedURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.UserAgent)
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载订阅失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("订阅服务器返回状态码: %d", resp.StatusCode)
+	}
+
+	// 多读取一个字节用于判断是否超出大小限制
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.MaxBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取订阅内容失败: %v", err)
+	}
+	if int64(len(body)) > f.MaxBodySize {
+		return nil, fmt.Errorf("订阅内容超过 %d 字节限制", f.MaxBodySize)
+	}
+
+	return parseSubscriptionBody(body)
+}
+
+// FetchAll 并发下载多个订阅，返回合并后的节点与每个订阅的处理结果
+// 同时最多下载 subscriptionConcurrency 个，单个订阅失败不会影响其他订阅
+func (f *SubscriptionFetcher) FetchAll(urls []string) ([]ProxyNode, []SourceResult) {
+	var wg sync.WaitGroup
+	nodeLists := make([][]ProxyNode, len(urls))
+	results := make([]SourceResult, len(urls))
+	slots := make(chan struct{}, subscriptionConcurrency)
+
+	for i, rawURL := range urls {
+		wg.Add(1)
+		go func(index int, u string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results[index] = SourceResult{Source: u}
+
+			nodes, err := f.FetchNodes(u)
+			if err != nil {
+				results[index].Error = err.Error()
+				return
+			}
+
+			nodeLists[index] = nodes
+			results[index].Success = true
+			results[index].NodeCount = len(nodes)
+		}(i, rawURL)
+	}
+
+	wg.Wait()
+
+	// 按订阅顺序合并节点
+	var allNodes []ProxyNode
+	for _, nodes := range nodeLists {
+		allNodes = append(allNodes, nodes...)
+	}
+	return allNodes, results
+}
+
+// parseSubscriptionBody 解析订阅内容，支持 Clash YAML、Base64 编码内容以及逐行分享链接
+func parseSubscriptionBody(body []byte) ([]ProxyNode, error) {
+	body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+	return ParseProxyLinks(string(body))
+}
+
+// normalizeSubscriptionURLs 去除空白与重复的订阅地址
+func normalizeSubscriptionURLs(urls []string) []string {
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, u := range urls {
+		u = strings.TrimSpace(u)
+		if u == "" || seen[u] {
+			continue
+		}
+		seen[u] = true
+		normalized = append(normalized, u)
+	}
+	return normalized
+}
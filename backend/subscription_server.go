@@ -0,0 +1,200 @@
+// backend/subscription_server.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// storedNodesSuffix 生成订阅时保存的节点集文件后缀，订阅接口据此按客户端格式重新渲染
+const storedNodesSuffix = ".nodes.json"
+
+// StoredSubscription 生成订阅时保存的节点集与生成选项
+type StoredSubscription struct {
+	Nodes     []ProxyNode     `json:"nodes"`
+	Options   GenerateRequest `json:"options"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// clientTargets 按顺序匹配 User-Agent 关键字 (小写) 与输出格式
+var clientTargets = []struct {
+	keyword string
+	target  string
+}{
+	{"sing-box", "sing-box"},
+	// 基于 mihomo 内核的客户端需要先于旧版 Clash 客户端匹配
+	{"mihomo", "clash"},
+	{"clash.meta", "clash"},
+	{"clashmeta", "clash"},
+	{"clash-meta", "clash"},
+	{"clash meta", "clash"},
+	{"clash-verge", "clash"},
+	{"flclash", "clash"},
+	{"stash", "clash"},
+	{"clashx meta", "clash"},
+	{"clashforwindows", "clash-premium"},
+	{"clashforandroid", "clash-premium"},
+	{"clashx", "clash-premium"},
+	{"clash", "clash"},
+	{"surge", "surge"},
+	{"quantumult", "quantumultx"},
+	{"loon", "loon"},
+	{"shadowrocket", "base64"},
+	// 关键字按子串匹配，较长的关键字需排在其前缀之前
+	{"v2rayng", "base64"},
+	{"v2rayn", "base64"},
+	{"nekobox", "base64"},
+	{"nekoray", "base64"},
+	{"v2box", "base64"},
+}
+
+// normalizeFormat 将输出格式及其别名统一为标准名称，未知格式返回 false
+func normalizeFormat(format string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "clash", "mihomo", "meta", "clash-meta":
+		return "clash", true
+	case "clash-premium", "premium", "clash-legacy":
+		return "clash-premium", true
+	case "sing-box", "singbox":
+		return "sing-box", true
+	case "surge":
+		return "surge", true
+	case "quantumultx", "quanx", "qx":
+		return "quantumultx", true
+	case "loon":
+		return "loon", true
+	case "base64", "v2ray", "v2rayn", "shadowrocket", "sharelink":
+		return "base64", true
+	default:
+		return "", false
+	}
+}
+
+// detectClientTarget 根据客户端 User-Agent 判断需要的输出格式，无法识别时返回空字符串
+func detectClientTarget(userAgent string) string {
+	userAgent = strings.ToLower(userAgent)
+	for _, client := range clientTargets {
+		if strings.Contains(userAgent, client.keyword) {
+			return client.target
+		}
+	}
+	return ""
+}
+
+// saveStoredSubscription 保存生成订阅所用的节点集与选项
+func saveStoredSubscription(subscriptionDir, configName string, nodes []ProxyNode, req GenerateRequest) error {
+	// 原始链接与订阅地址已解析为节点，无需重复保存
+	req.Links = ""
+	req.SubscriptionURLs = nil
+
+	data, err := json.Marshal(StoredSubscription{
+		Nodes:     nodes,
+		Options:   req,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("序列化节点集失败: %v", err)
+	}
+	return os.WriteFile(filepath.Join(subscriptionDir, configName+storedNodesSuffix), data, 0644)
+}
+
+// loadStoredSubscription 读取保存的节点集
+func loadStoredSubscription(subscriptionDir, configName string) (*StoredSubscription, error) {
+	data, err := os.ReadFile(filepath.Join(subscriptionDir, configName+storedNodesSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	var stored StoredSubscription
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("节点集文件损坏: %v", err)
+	}
+	return &stored, nil
+}
+
+// SubscriptionHandler 提供订阅文件下载
+// 通过 ?target= 参数或 User-Agent 判断客户端需要的格式，与已生成文件的格式不同时，
+// 使用保存的节点集即时渲染；否则直接返回已生成 (可能被用户编辑过) 的文件
+func SubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "只支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	subscriptionDir := "../subscriptions"
+	if _, err := os.Stat("/app"); err == nil {
+		subscriptionDir = "/app/subscriptions"
+	}
+
+	filename := strings.TrimPrefix(r.URL.Path, "/subscriptions/")
+	if filename == "" || filename != filepath.Base(filename) || strings.HasPrefix(filename, ".") ||
+		strings.HasSuffix(filename, storedNodesSuffix) {
+		http.NotFound(w, r)
+		return
+	}
+
+	ext := filepath.Ext(filename)
+	configName := filename
+	if subscriptionFileExts[ext] {
+		configName = strings.TrimSuffix(filename, ext)
+	}
+
+	// 确定客户端需要的格式，显式参数优先于 User-Agent
+	target := ""
+	if rawTarget := r.URL.Query().Get("target"); rawTarget != "" {
+		normalized, ok := normalizeFormat(rawTarget)
+		if !ok {
+			http.Error(w, fmt.Sprintf("不支持的订阅格式: %s", rawTarget), http.StatusBadRequest)
+			return
+		}
+		target = normalized
+	} else {
+		target = detectClientTarget(r.UserAgent())
+	}
+
+	stored, err := loadStoredSubscription(subscriptionDir, configName)
+	if err != nil {
+		// 没有节点集 (旧版本生成或手动保存的配置)，只能原样返回文件
+		if r.URL.Query().Get("target") != "" {
+			http.Error(w, "该订阅不支持格式转换", http.StatusNotFound)
+			return
+		}
+		http.ServeFile(w, r, filepath.Join(subscriptionDir, filename))
+		return
+	}
+
+	// 已生成文件的格式，.txt 始终为 Base64 分享链接
+	fileTarget, _ := normalizeFormat(stored.Options.Format)
+	if ext == ".txt" {
+		fileTarget = "base64"
+	}
+	if target == "" || (target == fileTarget && subscriptionFileExts[ext]) {
+		http.ServeFile(w, r, filepath.Join(subscriptionDir, filename))
+		return
+	}
+
+	options := stored.Options
+	options.Format = target
+	content, contentExt, _, err := generateConfigContent(stored.Nodes, configName, options)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("生成配置失败: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	switch contentExt {
+	case ".yaml":
+		w.Header().Set("Content-Type", "text/yaml; charset=utf-8")
+	case ".json":
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename*=UTF-8''%s", url.PathEscape(configName+contentExt)))
+	w.Write([]byte(content))
+}
@@ -0,0 +1,44 @@
+// backend/subscription_server_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectClientTarget(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		target    string
+	}{
+		{"clash-verge/v1.3.8", "clash"},
+		{"mihomo/1.18.0", "clash"},
+		{"ClashX Meta/1.3.0", "clash"},
+		{"ClashX/1.95.1", "clash-premium"},
+		{"ClashforWindows/0.20.39", "clash-premium"},
+		{"SFA/1.9.0 (sing-box 1.9.0)", "sing-box"},
+		{"Surge iOS/2920", "surge"},
+		{"Quantumult%20X/1.4.1", "quantumultx"},
+		{"Loon/3.1.6", "loon"},
+		{"Shadowrocket/2.2.35", "base64"},
+		{"v2rayN/6.42", "base64"},
+		{"v2rayNG/1.8.17", "base64"},
+		{"Mozilla/5.0", ""},
+	}
+	for _, tt := range tests {
+		if got := detectClientTarget(tt.userAgent); got != tt.target {
+			t.Errorf("detectClientTarget(%q) = %q, want %q", tt.userAgent, got, tt.target)
+		}
+	}
+}
+
+// TestClientTargetsOrder 关键字按子串匹配，排在前面的关键字不能是后面关键字的子串，否则后者永远不会被匹配
+func TestClientTargetsOrder(t *testing.T) {
+	for i, client := range clientTargets {
+		for _, earlier := range clientTargets[:i] {
+			if strings.Contains(client.keyword, earlier.keyword) {
+				t.Errorf("关键字 %q 被排在前面的 %q 抢先匹配", client.keyword, earlier.keyword)
+			}
+		}
+	}
+}
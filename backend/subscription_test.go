@@ -0,0 +1,275 @@
+// backend/subscription_test.go
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testTrojanLink = "trojan://secret@hk.example.com:443?sni=hk.example.com#HK%2001"
+	testSSLink     = "ss://YWVzLTEyOC1nY206cGFzcw@jp.example.com:8388#JP%2001"
+)
+
+// newTestFetcher 创建连接本机 httptest 服务的下载器，其余限制与默认下载器相同
+func newTestFetcher(userAgent string) *SubscriptionFetcher {
+	fetcher := NewSubscriptionFetcher(userAgent)
+	fetcher.Client.Transport = &http.Transport{}
+	return fetcher
+}
+
+func TestFetchNodesBodies(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		names []string
+	}{
+		{
+			name:  "plain links",
+			body:  testTrojanLink + "\n" + testSSLink + "\n",
+			names: []string{"HK 01", "JP 01"},
+		},
+		{
+			name:  "base64",
+			body:  base64.StdEncoding.EncodeToString([]byte(testTrojanLink + "\n" + testSSLink)),
+			names: []string{"HK 01", "JP 01"},
+		},
+		{
+			name:  "base64 with BOM",
+			body:  "\xef\xbb\xbf" + base64.StdEncoding.EncodeToString([]byte(testTrojanLink)),
+			names: []string{"HK 01"},
+		},
+		{
+			name: "clash yaml",
+			body: `proxies:
+  - {name: "SG 01", type: ss, server: sg.example.com, port: 8388, cipher: aes-128-gcm, password: pass}
+  - name: US 01
+    type: trojan
+    server: us.example.com
+    port: 443
+    password: secret
+`,
+			names: []string{"SG 01", "US 01"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			nodes, err := newTestFetcher("").FetchNodes(server.URL)
+			if err != nil {
+				t.Fatalf("FetchNodes: %v", err)
+			}
+			var names []string
+			for _, node := range nodes {
+				names = append(names, node.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.names, ",") {
+				t.Errorf("names = %v, want %v", names, tt.names)
+			}
+		})
+	}
+}
+
+func TestFetchNodesUserAgent(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		fmt.Fprint(w, testTrojanLink)
+	}))
+	defer server.Close()
+
+	if _, err := newTestFetcher("").FetchNodes(server.URL); err != nil {
+		t.Fatalf("FetchNodes: %v", err)
+	}
+	if !strings.HasPrefix(userAgent, "ClashLink/") {
+		t.Errorf("default User-Agent = %q, want ClashLink/...", userAgent)
+	}
+
+	if _, err := newTestFetcher("clash-verge/v1.3.8").FetchNodes(server.URL); err != nil {
+		t.Fatalf("FetchNodes: %v", err)
+	}
+	if userAgent != "clash-verge/v1.3.8" {
+		t.Errorf("custom User-Agent = %q", userAgent)
+	}
+}
+
+func TestFetchNodesSizeLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Repeat(testTrojanLink+"\n", 10))
+	}))
+	defer server.Close()
+
+	fetcher := newTestFetcher("")
+	fetcher.MaxBodySize = int64(len(testTrojanLink))
+	if _, err := fetcher.FetchNodes(server.URL); err == nil || !strings.Contains(err.Error(), "字节限制") {
+		t.Errorf("err = %v, want size limit error", err)
+	}
+}
+
+func TestFetchNodesRedirectLimit(t *testing.T) {
+	// /redirect/N 重定向 N 次后返回订阅内容
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var remaining int
+		fmt.Sscanf(r.URL.Path, "/redirect/%d", &remaining)
+		if remaining > 0 {
+			http.Redirect(w, r, fmt.Sprintf("/redirect/%d", remaining-1), http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, testTrojanLink)
+	}))
+	defer server.Close()
+
+	fetcher := newTestFetcher("")
+	if _, err := fetcher.FetchNodes(fmt.Sprintf("%s/redirect/%d", server.URL, subscriptionMaxRedirects-1)); err != nil {
+		t.Errorf("%d redirects: %v", subscriptionMaxRedirects-1, err)
+	}
+	_, err := fetcher.FetchNodes(fmt.Sprintf("%s/redirect/%d", server.URL, subscriptionMaxRedirects))
+	if err == nil || !strings.Contains(err.Error(), "重定向次数达到") {
+		t.Errorf("err = %v, want redirect limit error", err)
+	}
+}
+
+func TestFetchNodesTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	fetcher := newTestFetcher("")
+	fetcher.Client.Timeout = 100 * time.Millisecond
+	start := time.Now()
+	if _, err := fetcher.FetchNodes(server.URL); err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("timeout took %v", elapsed)
+	}
+}
+
+func TestFetchNodesStatusCode(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	if _, err := newTestFetcher("").FetchNodes(server.URL); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("err = %v, want status code error", err)
+	}
+}
+
+func TestFetchNodesRejectsPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testTrojanLink)
+	}))
+	defer server.Close()
+
+	// 默认下载器不允许访问本机的 httptest 服务
+	if _, err := NewSubscriptionFetcher("").FetchNodes(server.URL); err == nil || !strings.Contains(err.Error(), "内网") {
+		t.Errorf("err = %v, want private address error", err)
+	}
+
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"10.0.0.1:80", false},
+		{"172.16.5.4:443", false},
+		{"192.168.1.1:80", false},
+		{"169.254.169.254:80", false},
+		{"[fe80::1]:80", false},
+		{"[fd00::1]:80", false},
+		{"0.0.0.0:80", false},
+		{"100.64.0.1:80", false},
+		{"100.127.255.254:80", false},
+		{"192.0.0.8:80", false},
+		{"192.0.2.1:80", false},
+		{"192.88.99.1:80", false},
+		{"198.18.0.1:80", false},
+		{"198.19.255.1:80", false},
+		{"198.51.100.1:80", false},
+		{"203.0.113.1:80", false},
+		{"240.0.0.1:80", false},
+		{"255.255.255.255:80", false},
+		{"[::ffff:10.0.0.1]:80", false},
+		{"[64:ff9b::a9fe:a9fe]:80", false},
+		{"[64:ff9b:1::1]:80", false},
+		{"[2002:c0a8:101::1]:80", false},
+		{"[100::1]:80", false},
+		{"[2001::1]:80", false},
+		{"[2001:db8::1]:80", false},
+		{"[ff02::1]:80", false},
+		{"100.63.255.255:80", true},
+		{"100.128.0.1:80", true},
+		{"[64:ff9b::808:808]:443", true},
+		{"[2002:808:808::1]:443", true},
+		{"8.8.8.8:443", true},
+		{"[2001:4860:4860::8888]:443", true},
+	}
+	for _, tt := range tests {
+		err := rejectPrivateAddress("tcp", tt.address, nil)
+		if (err == nil) != tt.allowed {
+			t.Errorf("rejectPrivateAddress(%s) = %v, allowed = %v", tt.address, err, tt.allowed)
+		}
+	}
+}
+
+func TestFetchAllConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+		if r.URL.Path == "/fail" {
+			http.NotFound(w, r)
+		} else {
+			fmt.Fprintf(w, "trojan://secret@example.com:443#%s", strings.TrimPrefix(r.URL.Path, "/"))
+		}
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	var urls []string
+	for i := 0; i < 10; i++ {
+		urls = append(urls, fmt.Sprintf("%s/node%d", server.URL, i))
+	}
+	urls = append(urls, server.URL+"/fail")
+
+	nodes, results := newTestFetcher("").FetchAll(urls)
+	if maxActive > subscriptionConcurrency {
+		t.Errorf("max concurrent downloads = %d, want <= %d", maxActive, subscriptionConcurrency)
+	}
+	if len(nodes) != 10 {
+		t.Fatalf("got %d nodes, want 10", len(nodes))
+	}
+	for i, node := range nodes {
+		if want := fmt.Sprintf("node%d", i); node.Name != want {
+			t.Errorf("nodes[%d] = %s, want %s", i, node.Name, want)
+		}
+	}
+	if last := results[len(results)-1]; last.Success || last.Error == "" {
+		t.Errorf("failed source result = %+v", last)
+	}
+}
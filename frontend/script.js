@@ -73,7 +73,8 @@ function setupEventListeners() {
     document.getElementById('generateBtn').addEventListener('click', generateSubscription);
     
     // 复制URL按钮
-    document.getElementById('copyUrlBtn').addEventListener('click', copySubscriptionUrl);
+    document.getElementById('copyUrlBtn').addEventListener('click', () => copySubscriptionUrl('subscriptionUrl', 'copyUrlBtn'));
+    document.getElementById('copyShareLinkBtn').addEventListener('click', () => copySubscriptionUrl('shareLinkUrl', 'copyShareLinkBtn'));
     
     // 配置预览切换
     document.getElementById('toggleConfig').addEventListener('click', toggleConfigPreview);
@@ -134,14 +135,19 @@ function logout() {
 // 生成订阅
 async function generateSubscription() {
     const nodeLinks = document.getElementById('nodeLinks').value.trim();
-    if (!nodeLinks) {
-        showMessage('请输入节点链接', 'error');
+    const subscriptionUrls = document.getElementById('subscriptionUrls').value
+        .split('\n')
+        .map(url => url.trim())
+        .filter(url => url.length > 0);
+    if (!nodeLinks && subscriptionUrls.length === 0) {
+        showMessage('请输入节点链接或订阅地址', 'error');
         return;
     }
     
     const generateBtn = document.getElementById('generateBtn');
     const checkNodes = document.getElementById('checkNodes').checked;
     const onlyOnline = document.getElementById('onlyOnline').checked;
+    const testUrl = checkNodes ? document.getElementById('testUrl').value.trim() : '';
     const configName = document.getElementById('configName').value.trim() || 
                       document.getElementById('defaultConfigName').value.trim() || 'ClashLink配置';
     
@@ -151,8 +157,44 @@ async function generateSubscription() {
     const allowLan = document.getElementById('allowLan').checked;
     const logLevel = document.getElementById('logLevel').value;
     const dnsMode = document.getElementById('dnsMode').value;
+    const outputFormat = document.getElementById('outputFormat').value;
+    const ruleTemplate = document.getElementById('ruleTemplate').value;
     const enableIPv6 = document.getElementById('enableIPv6').checked;
+    const regionGroups = document.getElementById('regionGroups').checked;
+    const sortBy = document.getElementById('sortBy').value;
+    if (sortBy === 'latency' && !checkNodes) {
+        showMessage('按延迟排序需要开启节点连通性检测', 'error');
+        return;
+    }
     const customRules = document.getElementById('customRules').value.trim();
+    const customProxyGroups = document.getElementById('customProxyGroups').value.trim();
+    const nodeFilter = {
+        include: document.getElementById('filterInclude').value.trim(),
+        exclude: document.getElementById('filterExclude').value.trim(),
+        protocols: document.getElementById('filterProtocols').value
+            .split(',')
+            .map(protocol => protocol.trim())
+            .filter(protocol => protocol.length > 0),
+        ports: document.getElementById('filterPorts').value.trim()
+    };
+    const rename = {
+        template: document.getElementById('renameTemplate').value.trim(),
+        rules: parseRenameRules(document.getElementById('renameRules').value)
+    };
+    
+    let proxyGroups = [];
+    if (customProxyGroups) {
+        try {
+            proxyGroups = JSON.parse(customProxyGroups);
+        } catch (e) {
+            showMessage('自定义代理组不是有效的 JSON', 'error');
+            return;
+        }
+        if (!Array.isArray(proxyGroups)) {
+            showMessage('自定义代理组必须是 JSON 数组', 'error');
+            return;
+        }
+    }
     
     // 显示加载状态
     generateBtn.classList.add('loading');
@@ -168,16 +210,25 @@ async function generateSubscription() {
             },
             body: JSON.stringify({
                 links: nodeLinks,
+                subscriptionUrls: subscriptionUrls,
                 checkNodes: checkNodes,
                 onlyOnline: onlyOnline,
+                testUrl: testUrl,
                 configName: configName,
                 mixedPort: mixedPort,
                 controllerPort: controllerPort,
                 allowLan: allowLan,
                 logLevel: logLevel,
                 dnsMode: dnsMode,
+                format: outputFormat,
+                ruleTemplate: ruleTemplate,
                 enableIPv6: enableIPv6,
-                customRules: customRules
+                regionGroups: regionGroups,
+                sortBy: sortBy,
+                nodeFilter: nodeFilter,
+                rename: rename,
+                customRules: customRules,
+                proxyGroups: proxyGroups
             })
         });
         
@@ -185,7 +236,21 @@ async function generateSubscription() {
         
         if (response.ok && data.success) {
             displayResults(data);
-            showMessage('订阅生成成功！', 'success');
+            const failedSources = (data.sourceResults || []).filter(result => !result.success);
+            const skippedNodes = data.skippedNodes || [];
+            const warnings = data.warnings || [];
+            if (failedSources.length > 0) {
+                showMessage(`订阅生成成功，但有 ${failedSources.length} 个来源获取失败: ${failedSources.map(result => result.source).join(', ')}`, 'warning');
+            } else if (skippedNodes.length > 0) {
+                showMessage(`订阅生成成功，以下节点因目标格式不支持被跳过: ${skippedNodes.join('; ')}`, 'warning');
+            } else if (warnings.length > 0) {
+                showMessage(`订阅生成成功，${warnings.join('; ')}`, 'warning');
+            } else {
+                showMessage('订阅生成成功！', 'success');
+            }
+        } else if (data.ruleErrors && data.ruleErrors.length > 0) {
+            const ruleErrors = data.ruleErrors.map(ruleError => `第 ${ruleError.line} 行 ${ruleError.rule}: ${ruleError.error}`);
+            showMessage(`${data.message}: ${ruleErrors.join('; ')}`, 'error');
         } else {
             showMessage(data.message || '生成失败', 'error');
         }
@@ -212,6 +277,7 @@ function displayResults(data) {
     
     // 设置订阅链接
     subscriptionUrl.value = data.subscriptionURL || '';
+    document.getElementById('shareLinkUrl').value = data.shareLinkUrl || '';
     
     // 显示节点状态
     if (data.nodeStatuses && data.nodeStatuses.length > 0) {
@@ -221,6 +287,21 @@ function displayResults(data) {
         nodeStatus.style.display = 'none';
     }
     
+    // 显示被过滤、合并与改名的节点
+    const excludedNodes = document.getElementById('excludedNodes');
+    const nodeChanges = [
+        ...(data.excludedNodes || []),
+        ...(data.mergedNodes || []).map(node => ({ name: node.name, server: '', reason: `与节点 ${node.mergedInto} 配置相同，已合并` })),
+        ...(data.renamedNodes || []).map(node => ({ name: node.from, server: '', reason: `名称重复，已改名为 ${node.to}` })),
+        ...(data.unverifiedNodes || []).map(name => ({ name, server: '', reason: 'UDP 节点无法验证，仍保留在配置中' }))
+    ];
+    if (nodeChanges.length > 0) {
+        displayExcludedNodes(nodeChanges);
+        excludedNodes.style.display = 'block';
+    } else {
+        excludedNodes.style.display = 'none';
+    }
+    
     // 设置配置内容
     configContent.textContent = data.configContent || '';
     
@@ -231,6 +312,45 @@ function displayResults(data) {
     };
 }
 
+// 解析重命名替换规则，每行格式为 "正则 => 替换内容"
+function parseRenameRules(text) {
+    return text.split('\n')
+        .filter(line => line.trim().length > 0)
+        .map(line => {
+            const separator = line.indexOf('=>');
+            if (separator === -1) {
+                return { pattern: line.trim(), replace: '' };
+            }
+            return {
+                pattern: line.slice(0, separator).trim(),
+                replace: line.slice(separator + 2).trim()
+            };
+        });
+}
+
+// 显示被过滤、合并或改名的节点
+function displayExcludedNodes(excludedNodes) {
+    const excludedList = document.getElementById('excludedList');
+    excludedList.innerHTML = '';
+    excludedNodes.forEach(node => {
+        const item = document.createElement('div');
+        item.className = 'status-item offline';
+        
+        const name = document.createElement('div');
+        name.className = 'node-name';
+        name.textContent = node.name;
+        const server = document.createElement('div');
+        server.className = 'node-server';
+        server.textContent = node.server;
+        const reason = document.createElement('div');
+        reason.className = 'node-error';
+        reason.textContent = node.reason;
+        
+        item.append(name, server, reason);
+        excludedList.appendChild(item);
+    });
+}
+
 // 显示节点状态
 function displayNodeStatus(statuses, summary) {
     const statusSummary = document.getElementById('statusSummary');
@@ -243,6 +363,8 @@ function displayNodeStatus(statuses, summary) {
             <div class="summary-item online">在线: ${summary.online}</div>
             <div class="summary-item offline">离线: ${summary.offline}</div>
             <div class="summary-item timeout">超时: ${summary.timeout}</div>
+            ${summary.auth_failed > 0 ? `<div class="summary-item auth_failed">认证失败: ${summary.auth_failed}</div>` : ''}
+            ${summary.unverified > 0 ? `<div class="summary-item unverified">未验证: ${summary.unverified}</div>` : ''}
         `;
     }
     
@@ -254,12 +376,16 @@ function displayNodeStatus(statuses, summary) {
         
         const latencyText = status.latency > 0 ? `${status.latency}ms` : '-';
         const errorText = status.error ? `错误: ${status.error}` : '';
+        const tlsText = status.tls ? getTLSText(status.tls) : '';
+        const proxyTestText = status.proxyTest ? getProxyTestText(status.proxyTest) : '';
         
         statusItem.innerHTML = `
             <div class="node-name">${status.node.name}</div>
             <div class="node-server">${status.node.server}:${status.node.port}</div>
             <div class="node-status">${getStatusText(status.status)}</div>
             <div class="node-latency">${latencyText}</div>
+            ${tlsText ? `<div class="node-tls">${tlsText}</div>` : ''}
+            ${proxyTestText ? `<div class="node-tls">${proxyTestText}</div>` : ''}
             ${errorText ? `<div class="node-error">${errorText}</div>` : ''}
         `;
         
@@ -267,19 +393,47 @@ function displayNodeStatus(statuses, summary) {
     });
 }
 
+// 获取 TLS 握手检测文本
+function getTLSText(tls) {
+    if (!tls.version) {
+        return `TLS (SNI: ${tls.serverName}) 握手失败`;
+    }
+    const parts = [`${tls.version} 握手 ${tls.handshakeLatency}ms`];
+    if (tls.certNotAfter) {
+        parts.push(tls.certExpiresIn < 0 ? '证书已过期' : `证书剩余 ${tls.certExpiresIn} 天`);
+    }
+    if (tls.verifyError) {
+        parts.push(`证书校验失败: ${tls.verifyError}`);
+    }
+    return parts.join(' · ');
+}
+
+// 获取通过节点访问测试地址的结果文本
+function getProxyTestText(proxyTest) {
+    if (proxyTest.skipped) {
+        return `未测试代理协议: ${proxyTest.skipped}`;
+    }
+    if (proxyTest.statusCode) {
+        return `测试地址响应 ${proxyTest.statusCode}，端到端 ${proxyTest.latency}ms`;
+    }
+    return '';
+}
+
 // 获取状态文本
 function getStatusText(status) {
     const statusMap = {
         'online': '在线',
         'offline': '离线',
-        'timeout': '超时'
+        'timeout': '超时',
+        'auth_failed': '认证失败',
+        'unverified': '未验证'
     };
     return statusMap[status] || status;
 }
 
 // 复制订阅链接
-async function copySubscriptionUrl() {
-    const urlInput = document.getElementById('subscriptionUrl');
+async function copySubscriptionUrl(inputId, buttonId) {
+    const urlInput = document.getElementById(inputId);
     if (!urlInput.value) {
         showMessage('没有可复制的链接', 'error');
         return;
@@ -290,7 +444,7 @@ async function copySubscriptionUrl() {
         showMessage('链接已复制到剪贴板', 'success');
         
         // 视觉反馈
-        const copyBtn = document.getElementById('copyUrlBtn');
+        const copyBtn = document.getElementById(buttonId);
         const originalText = copyBtn.textContent;
         copyBtn.textContent = '已复制';
         copyBtn.classList.add('copied');
@@ -328,7 +482,8 @@ function downloadConfig() {
         return;
     }
     
-    const blob = new Blob([window.currentConfig.content], { type: 'text/yaml' });
+    const isJSON = (window.currentConfig.filename || '').endsWith('.json');
+    const blob = new Blob([window.currentConfig.content], { type: isJSON ? 'application/json' : 'text/yaml' });
     const url = URL.createObjectURL(blob);
     const a = document.createElement('a');
     a.href = url;
@@ -389,6 +544,10 @@ async function resetSubscription() {
             if (subscriptionUrl) {
                 subscriptionUrl.value = '';
             }
+            const shareLinkUrl = document.getElementById('shareLinkUrl');
+            if (shareLinkUrl) {
+                shareLinkUrl.value = '';
+            }
             
             // 隐藏结果区域
             const resultSection = document.getElementById('resultSection');
@@ -583,9 +742,21 @@ function saveDefaultConfig() {
         allowLan: document.getElementById('allowLan').checked,
         logLevel: document.getElementById('logLevel').value,
         dnsMode: document.getElementById('dnsMode').value,
+        outputFormat: document.getElementById('outputFormat').value,
+        ruleTemplate: document.getElementById('ruleTemplate').value,
         enableIPv6: document.getElementById('enableIPv6').checked,
+        regionGroups: document.getElementById('regionGroups').checked,
+        sortBy: document.getElementById('sortBy').value,
+        filterInclude: document.getElementById('filterInclude').value.trim(),
+        filterExclude: document.getElementById('filterExclude').value.trim(),
+        filterProtocols: document.getElementById('filterProtocols').value.trim(),
+        filterPorts: document.getElementById('filterPorts').value.trim(),
+        renameTemplate: document.getElementById('renameTemplate').value.trim(),
+        renameRules: document.getElementById('renameRules').value.trim(),
+        testUrl: document.getElementById('testUrl').value.trim(),
         configName: configName,
-        customRules: customRules
+        customRules: customRules,
+        customProxyGroups: document.getElementById('customProxyGroups').value.trim()
     };
     
     // 保存到localStorage
@@ -608,9 +779,21 @@ function loadDefaultConfig() {
             if (config.allowLan !== undefined) document.getElementById('allowLan').checked = config.allowLan;
             if (config.logLevel) document.getElementById('logLevel').value = config.logLevel;
             if (config.dnsMode) document.getElementById('dnsMode').value = config.dnsMode;
+            if (config.outputFormat) document.getElementById('outputFormat').value = config.outputFormat;
+            if (config.ruleTemplate) document.getElementById('ruleTemplate').value = config.ruleTemplate;
             if (config.enableIPv6 !== undefined) document.getElementById('enableIPv6').checked = config.enableIPv6;
+            if (config.regionGroups !== undefined) document.getElementById('regionGroups').checked = config.regionGroups;
+            if (config.sortBy !== undefined) document.getElementById('sortBy').value = config.sortBy;
+            if (config.filterInclude !== undefined) document.getElementById('filterInclude').value = config.filterInclude;
+            if (config.filterExclude !== undefined) document.getElementById('filterExclude').value = config.filterExclude;
+            if (config.filterProtocols !== undefined) document.getElementById('filterProtocols').value = config.filterProtocols;
+            if (config.filterPorts !== undefined) document.getElementById('filterPorts').value = config.filterPorts;
+            if (config.renameTemplate !== undefined) document.getElementById('renameTemplate').value = config.renameTemplate;
+            if (config.renameRules !== undefined) document.getElementById('renameRules').value = config.renameRules;
+            if (config.testUrl !== undefined) document.getElementById('testUrl').value = config.testUrl;
             if (config.configName) document.getElementById('defaultConfigName').value = config.configName;
             if (config.customRules) document.getElementById('customRules').value = config.customRules;
+            if (config.customProxyGroups) document.getElementById('customProxyGroups').value = config.customProxyGroups;
             
             showMessage('📥 默认配置已加载', 'info');
         } catch (e) {
@@ -635,9 +818,21 @@ function resetDefaultConfig() {
         document.getElementById('allowLan').checked = true;
         document.getElementById('logLevel').value = 'info';
         document.getElementById('dnsMode').value = 'fake-ip';
+        document.getElementById('outputFormat').value = 'clash';
+        document.getElementById('ruleTemplate').value = 'default';
         document.getElementById('enableIPv6').checked = false;
+        document.getElementById('regionGroups').checked = false;
+        document.getElementById('sortBy').value = '';
+        document.getElementById('filterInclude').value = '';
+        document.getElementById('filterExclude').value = '剩余流量|到期时间|过期时间|官网|套餐|重置';
+        document.getElementById('filterProtocols').value = '';
+        document.getElementById('filterPorts').value = '';
+        document.getElementById('renameTemplate').value = '';
+        document.getElementById('renameRules').value = '';
+        document.getElementById('testUrl').value = '';
         document.getElementById('defaultConfigName').value = 'ClashLink配置';
         document.getElementById('customRules').value = '';
+        document.getElementById('customProxyGroups').value = '';
         
         showMessage('🔄 默认配置已重置', 'info');
     }